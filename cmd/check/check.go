@@ -14,21 +14,76 @@ var Cmd = &cobra.Command{
 	Short: "Check system components",
 	Long: `Check the status of various system components including:
 - SSH connections to remote hosts
-- Prometheus services`,
+- System time, NTP and resource usage
+- systemd services and listening ports per role
+- TLS certificate expiry on endpoints and host files
+- Error patterns in remote logs
+- Configuration drift between nodes of the same role
+- Node-to-node network connectivity and DNS resolution
+- Prometheus services
+- Pushgateway push groups
+- Alertmanager cluster, silences and alerts
+- Exporter endpoints scraped directly
+- Generic HTTP endpoints and TCP ports from config`,
 	Run: runCheck,
 }
 
 func init() {
-	Cmd.Flags().StringP("component", "c", "", "Component to check (prometheus, system, ssh, all)")
+	Cmd.Flags().StringP("component", "c", "", "Component to check (prometheus, pushgateway, alertmanager, exporter, http, tcp, services, certificate, logs, drift, network, system, ssh, all)")
+	Cmd.Flags().Bool("diff", false, "Show unified diffs of drifted files against the majority version")
 }
 
 func runCheck(cmd *cobra.Command, args []string) {
 	component, _ := cmd.Flags().GetString("component")
 	cfg := config.GetConfig()
+	if diff, _ := cmd.Flags().GetBool("diff"); diff {
+		cfg.Drift.Diff = true
+	}
 
 	checkMgr := checker.NewManager(cfg)
 
 	results := checkMgr.Check(component)
 
 	output.FormatCheckResults(results)
+
+	if component == "network" || component == "all" {
+		formatNetworkMatrix(checkMgr)
+	}
+}
+
+// formatNetworkMatrix 以源节点×目标端口的矩阵展示网络检查结果
+func formatNetworkMatrix(checkMgr *checker.Manager) {
+	c, ok := checkMgr.Checker("network")
+	if !ok {
+		return
+	}
+	network, ok := c.(*checker.NetworkChecker)
+	if !ok {
+		return
+	}
+	matrix := network.Matrix()
+	if matrix == nil || len(matrix.Targets) == 0 {
+		return
+	}
+
+	cells := make([][]string, len(matrix.Sources))
+	failed := make([][]bool, len(matrix.Sources))
+	for i, source := range matrix.Sources {
+		cells[i] = make([]string, len(matrix.Targets))
+		failed[i] = make([]bool, len(matrix.Targets))
+		for j, target := range matrix.Targets {
+			cell, probed := matrix.Cells[source][target]
+			switch {
+			case !probed:
+				cells[i][j] = "-"
+			case cell.OK:
+				cells[i][j] = cell.FormatLatency()
+			default:
+				cells[i][j] = "FAIL"
+				failed[i][j] = true
+			}
+		}
+	}
+
+	output.FormatMatrix("Network Mesh", "Source \\ Target", matrix.Sources, matrix.Targets, cells, failed)
 }
@@ -0,0 +1,89 @@
+package facts
+
+import (
+	"fmt"
+	"ops_cli/internal/config"
+	"ops_cli/internal/facts"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/output"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// Cmd represents the facts command
+var Cmd = &cobra.Command{
+	Use:   "facts [flags]",
+	Short: "Collect host inventory facts",
+	Long: `Collect kernel, OS release, CPU count, memory and optionally Prometheus
+version from every host, cache them locally and render them as a table or JSON.`,
+	Run: runFacts,
+}
+
+func init() {
+	Cmd.Flags().Bool("cached", false, "Show the cached facts without connecting to hosts")
+	Cmd.Flags().Bool("http", false, "Also collect Prometheus build info over HTTP")
+	Cmd.Flags().StringP("output", "o", "table", "Output format (table, json)")
+}
+
+func runFacts(cmd *cobra.Command, args []string) {
+	cached, _ := cmd.Flags().GetBool("cached")
+	withHTTP, _ := cmd.Flags().GetBool("http")
+	format, _ := cmd.Flags().GetString("output")
+	if format == "json" {
+		// 标准输出只保留 JSON，日志写到标准错误
+		log.SetConsole(os.Stderr)
+	}
+
+	cfg := config.GetConfig()
+	path := facts.CachePath(cfg)
+
+	var cache *facts.Cache
+	if cached {
+		var err error
+		if cache, err = facts.Load(path); err != nil {
+			log.Error("Failed to load facts cache %s: %v", path, err)
+			return
+		}
+	} else {
+		cache = facts.Collect(cfg, withHTTP)
+		if err := facts.Save(path, cache); err != nil {
+			log.Error("Failed to save facts cache: %v", err)
+		} else {
+			log.Info("Facts cached to %s", path)
+		}
+	}
+
+	if format == "json" {
+		if err := output.FormatJSON(cache); err != nil {
+			log.Error("Failed to render facts: %v", err)
+		}
+		return
+	}
+
+	var rows [][]string
+	for _, host := range cache.Hosts {
+		rows = append(rows, []string{
+			host.IP,
+			host.Role,
+			host.Hostname,
+			host.OS,
+			host.Kernel,
+			strconv.Itoa(host.CPUs),
+			formatBytes(host.MemoryBytes),
+			host.PrometheusVersion,
+			host.Error,
+		})
+	}
+
+	title := fmt.Sprintf("Host Facts (collected %s)", cache.UpdatedAt.Format("2006-01-02 15:04:05"))
+	output.FormatTable(title, []string{"IP", "Role", "Hostname", "OS", "Kernel", "CPUs", "Memory", "Prometheus", "Error"}, rows)
+}
+
+func formatBytes(bytes int64) string {
+	if bytes <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f GiB", float64(bytes)/(1<<30))
+}
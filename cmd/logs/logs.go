@@ -0,0 +1,42 @@
+package logs
+
+import (
+	"ops_cli/internal/checker"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/output"
+
+	"github.com/spf13/cobra"
+)
+
+// Cmd represents the logs command
+var Cmd = &cobra.Command{
+	Use:   "logs [flags]",
+	Short: "Scan remote logs for error patterns",
+	Long: `Scan the log files and journald units configured under "logs" in config.yaml
+on every host, count matches per error pattern and show the most recent matching lines.`,
+	Run: runLogs,
+}
+
+func init() {
+	Cmd.Flags().StringP("component", "c", "", "Only scan logs of this component")
+	Cmd.Flags().Duration("since", 0, "Journal window to scan, overrides logs.since (e.g. 30m)")
+	Cmd.Flags().Int("lines", 0, "Lines to read from the end of log files, overrides logs.lines")
+}
+
+func runLogs(cmd *cobra.Command, args []string) {
+	component, _ := cmd.Flags().GetString("component")
+	since, _ := cmd.Flags().GetDuration("since")
+	lines, _ := cmd.Flags().GetInt("lines")
+
+	cfg := config.GetConfig()
+	if since > 0 {
+		cfg.Logs.Since = since
+	}
+	if lines > 0 {
+		cfg.Logs.Lines = lines
+	}
+
+	results := checker.NewLogsChecker(cfg).CheckComponent(component)
+
+	output.FormatCheckResults(results)
+}
@@ -0,0 +1,178 @@
+package query
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"ops_cli/internal/config"
+	"ops_cli/internal/query"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/output"
+	"strconv"
+	"time"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [flags]",
+	Short: "Measure query latency and cost on each host",
+	Long: `Run the queries in query.yaml, or an ad-hoc expression with -e, --runs times
+against each host with --concurrency requests in flight, and report p50/p90/p99
+latency, error rate, returned series and samples. Prometheus query statistics
+(stats=all) add the server-side evaluation time and the peak and total
+queryable samples when the server supports them. A warm-up round of
+--concurrency requests per query and host opens the connections and is not
+counted.
+
+Configured instant queries run at query_time and range queries over start/end
+from query.yaml unless --time, --start, --end or --step are given. With -e the
+expression runs as a range query when --start is set.`,
+	Run: runBench,
+}
+
+func init() {
+	benchCmd.Flags().StringP("type", "t", "all", "Type of configured queries to benchmark (query, query_range, all)")
+	benchCmd.Flags().StringP("config", "c", "", "Query configuration file path")
+	benchCmd.Flags().StringP("expr", "e", "", "Ad-hoc PromQL expression to benchmark instead of query.yaml")
+	benchCmd.Flags().IntP("runs", "n", 10, "Number of runs per query and host")
+	benchCmd.Flags().Int("concurrency", 1, "Number of concurrent requests per host")
+	benchCmd.Flags().String("time", "", "Evaluation time for instant queries")
+	benchCmd.Flags().String("start", "", "Start time for range queries")
+	benchCmd.Flags().String("end", "", "End time for range queries (default now)")
+	benchCmd.Flags().Duration("step", 0, "Step for range queries (default auto)")
+	benchCmd.Flags().StringSlice("host", nil, "Only query these host IPs")
+	benchCmd.Flags().StringSlice("role", nil, "Only query hosts with these roles")
+	benchCmd.Flags().StringArray("var", nil, "Template variable key=value, may be repeated")
+	benchCmd.Flags().Bool("skip-validate", false, "Send queries without parsing them locally first")
+	Cmd.AddCommand(benchCmd)
+}
+
+func runBench(cmd *cobra.Command, args []string) {
+	flags := cmd.Flags()
+	spec := query.BenchSpec{}
+	spec.Type, _ = flags.GetString("type")
+	spec.Expr, _ = flags.GetString("expr")
+	spec.Runs, _ = flags.GetInt("runs")
+	spec.Concurrency, _ = flags.GetInt("concurrency")
+	spec.Step, _ = flags.GetDuration("step")
+	spec.SkipValidate, _ = flags.GetBool("skip-validate")
+	if spec.Runs <= 0 || spec.Concurrency <= 0 {
+		log.Error("--runs and --concurrency must be positive")
+		return
+	}
+
+	// 配置的查询默认使用 query.yaml 中的时间，命令行参数优先
+	timeStr, _ := flags.GetString("time")
+	startStr, _ := flags.GetString("start")
+	endStr, _ := flags.GetString("end")
+	// -e 也使用 query.yaml 中的变量和时区，但不要求文件存在
+	queryConfig, _ := flags.GetString("config")
+	load := query.LoadConfig
+	if spec.Expr != "" {
+		load = query.LoadOptionalConfig
+	}
+	if err := load(queryConfig); err != nil {
+		log.Error("Failed to load query config: %v", err)
+		return
+	}
+	if spec.Expr == "" {
+		qc := query.GetConfig()
+		if !flags.Changed("time") {
+			timeStr = qc.Query.QueryTime
+		}
+		if !flags.Changed("start") {
+			startStr = qc.QueryRange.Start
+		}
+		if !flags.Changed("end") {
+			endStr = qc.QueryRange.End
+		}
+		if !flags.Changed("step") {
+			spec.Step = qc.QueryRange.Step
+		}
+	}
+
+	var err error
+	if spec.Time, err = query.ParseTime(timeStr); err != nil {
+		log.Error("Invalid time: %v", err)
+		return
+	}
+	if startStr != "" {
+		if spec.Start, err = query.ParseTime(startStr); err != nil {
+			log.Error("Invalid start: %v", err)
+			return
+		}
+		if spec.End, err = query.ParseTime(endStr); err != nil {
+			log.Error("Invalid end: %v", err)
+			return
+		}
+		if !spec.End.After(spec.Start) {
+			log.Error("End %s is not after start %s", query.FormatTime(spec.End), query.FormatTime(spec.Start))
+			return
+		}
+	} else if spec.Expr == "" && spec.Type != "query" {
+		log.Error("query_range.start is required to benchmark range queries")
+		return
+	}
+
+	pairs, _ := flags.GetStringArray("var")
+	if spec.Vars, err = query.ParseVars(pairs); err != nil {
+		log.Error("%v", err)
+		return
+	}
+
+	hosts, _ := flags.GetStringSlice("host")
+	roles, _ := flags.GetStringSlice("role")
+	selected := query.SelectHosts(config.GetConfig().IPs, hosts, roles)
+	if len(selected) == 0 {
+		log.Error("No hosts match --host %v --role %v", hosts, roles)
+		return
+	}
+	if spec.Expr == "" {
+		query.GetConfig().CLIVars = spec.Vars
+		if !preflight(cmd, selected, spec.Type) {
+			return
+		}
+	}
+
+	formatBenchReports(query.RunBench(spec, selected), spec)
+}
+
+// formatBenchReports 以表格输出压测结果，失败原因单独记录日志
+func formatBenchReports(reports []query.BenchReport, spec query.BenchSpec) {
+	var rows [][]string
+	for _, r := range reports {
+		eval, peak, queryable := "-", "-", "-"
+		if r.HasStats {
+			eval = formatLatency(r.EvalP50)
+			peak = strconv.FormatInt(r.PeakSamples, 10)
+			queryable = strconv.FormatInt(r.QueryableSamples, 10)
+		}
+		rows = append(rows, []string{
+			r.IP.IP,
+			r.IP.Role,
+			fmt.Sprintf("%s %s", r.Section, r.Name),
+			fmt.Sprintf("%d/%d", r.Runs-r.Errors, r.Runs),
+			fmt.Sprintf("%.1f%%", r.ErrorRate()*100),
+			formatLatency(r.P50),
+			formatLatency(r.P90),
+			formatLatency(r.P99),
+			strconv.Itoa(r.Series),
+			strconv.Itoa(r.Samples),
+			eval,
+			peak,
+			queryable,
+		})
+		if r.LastError != nil {
+			log.Error("%s on %s: %d of %d runs failed, last error: %v", r.Name, r.IP.IP, r.Errors, r.Runs, r.LastError)
+		}
+	}
+
+	title := fmt.Sprintf("Query Benchmark (%d runs, concurrency %d)", spec.Runs, spec.Concurrency)
+	output.FormatTable(title, []string{"IP", "Role", "Query", "OK", "Errors", "P50", "P90", "P99", "Series", "Samples", "Eval P50", "Peak Samples", "Queryable Samples"}, rows)
+}
+
+// formatLatency 将延迟格式化为毫秒，没有成功的执行时为 -
+func formatLatency(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
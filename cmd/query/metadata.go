@@ -0,0 +1,152 @@
+package query
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"ops_cli/internal/config"
+	"ops_cli/internal/query"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/output"
+)
+
+var labelsCmd = &cobra.Command{
+	Use:   "labels [flags]",
+	Short: "List label names on each host",
+	Run:   runMetadata(query.MetadataLabels),
+}
+
+var valuesCmd = &cobra.Command{
+	Use:   "values <label> [flags]",
+	Short: "List values of a label on each host, e.g. values __name__ for metric names",
+	Args:  cobra.ExactArgs(1),
+	Run:   runMetadata(query.MetadataValues),
+}
+
+var seriesCmd = &cobra.Command{
+	Use:   "series --match <selector> [flags]",
+	Short: "List series matching selectors on each host",
+	Run:   runMetadata(query.MetadataSeries),
+}
+
+var metadataCmd = &cobra.Command{
+	Use:   "metadata [metric] [flags]",
+	Short: "Show type, unit and help of metrics on each host",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runMetadata(query.MetadataMetrics),
+}
+
+func init() {
+	for _, c := range []*cobra.Command{labelsCmd, valuesCmd, seriesCmd, metadataCmd} {
+		c.Flags().StringP("config", "c", "", "Query configuration file path, used for the timezone")
+		c.Flags().StringSlice("host", nil, "Only query these host IPs")
+		c.Flags().StringSlice("role", nil, "Only query hosts with these roles")
+		c.Flags().String("view", query.ViewUnion, "Show every entry (union) or only entries that differ between hosts (diff)")
+		if c != metadataCmd {
+			// metadata 接口不支持选择器和时间范围
+			c.Flags().StringArray("match", nil, "Series selector, e.g. up{job=\"node\"}, may be repeated")
+			c.Flags().String("start", "", "Only consider series present after this time")
+			c.Flags().String("end", "", "Only consider series present before this time")
+		}
+		Cmd.AddCommand(c)
+	}
+}
+
+// runMetadata 返回执行某种元数据查询的命令
+func runMetadata(kind string) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		view, _ := flags.GetString("view")
+		if view != query.ViewUnion && view != query.ViewDiff {
+			log.Error("Unknown view %q, expected %s or %s", view, query.ViewUnion, query.ViewDiff)
+			return
+		}
+
+		// 使用 query.yaml 中的时区解析 --start 和 --end，不要求文件存在
+		queryConfig, _ := flags.GetString("config")
+		if err := query.LoadOptionalConfig(queryConfig); err != nil {
+			log.Error("Failed to load query config: %v", err)
+			return
+		}
+
+		r := query.MetadataRequest{Kind: kind}
+		switch kind {
+		case query.MetadataValues:
+			r.Label = args[0]
+		case query.MetadataMetrics:
+			if len(args) > 0 {
+				r.Metric = args[0]
+			}
+		}
+		if kind != query.MetadataMetrics {
+			var err error
+			r.Matchers, _ = flags.GetStringArray("match")
+			if start, _ := flags.GetString("start"); start != "" {
+				if r.Start, err = query.ParseTime(start); err != nil {
+					log.Error("Invalid --start: %v", err)
+					return
+				}
+			}
+			if end, _ := flags.GetString("end"); end != "" {
+				if r.End, err = query.ParseTime(end); err != nil {
+					log.Error("Invalid --end: %v", err)
+					return
+				}
+			}
+		}
+		if err := r.Validate(); err != nil {
+			log.Error("%v", err)
+			return
+		}
+
+		hosts, _ := flags.GetStringSlice("host")
+		roles, _ := flags.GetStringSlice("role")
+		selected := query.SelectHosts(config.GetConfig().IPs, hosts, roles)
+		if len(selected) == 0 {
+			log.Error("No hosts match --host %v --role %v", hosts, roles)
+			return
+		}
+
+		inv, failed := query.ExploreMetadata(r, selected)
+		if len(failed) > 0 {
+			output.FormatCheckResults(failed)
+		}
+		if len(inv.Hosts) == 0 {
+			return
+		}
+		title := fmt.Sprintf("%s (%d)", inv.Title, len(inv.Items))
+		if view == query.ViewDiff {
+			total := len(inv.Items)
+			inv = inv.Diff()
+			if len(inv.Items) == 0 {
+				fmt.Printf("\nAll %d entries are identical on %d hosts\n\n", total, len(inv.Hosts))
+				return
+			}
+			title = fmt.Sprintf("%s (%d of %d differ)", inv.Title, len(inv.Items), total)
+		}
+		formatInventory(title, inv)
+	}
+}
+
+// formatInventory 以条目×节点的矩阵展示元数据，缺失或与多数节点不同的单元格标红
+func formatInventory(title string, inv query.Inventory) {
+	var cols []string
+	if inv.Help != nil {
+		cols = append(cols, "help")
+	}
+	cols = append(cols, inv.Hosts...)
+
+	cells := make([][]string, len(inv.Items))
+	failed := make([][]bool, len(inv.Items))
+	for i, item := range inv.Items {
+		if inv.Help != nil {
+			cells[i] = append(cells[i], inv.Help[item])
+			failed[i] = append(failed[i], false)
+		}
+		for _, host := range inv.Hosts {
+			cell, differs := inv.FormatCell(item, host)
+			cells[i] = append(cells[i], cell)
+			failed[i] = append(failed[i], differs)
+		}
+	}
+	output.FormatMatrix(title, "Entry \\ Host", inv.Items, cols, cells, failed)
+}
@@ -2,6 +2,7 @@ package query
 
 import (
 	"github.com/spf13/cobra"
+	"ops_cli/internal/checker"
 	"ops_cli/internal/config"
 	"ops_cli/internal/query"
 	"ops_cli/pkg/log"
@@ -14,27 +15,292 @@ var Cmd = &cobra.Command{
 	Short: "Query Prometheus data",
 	Long: `Query Prometheus data using the configurations defined in query.yaml:
 - Query
-- Query Range`,
+- Query Range
+- Ad-hoc PromQL expressions given with -e`,
 	Run: runQuery,
 }
 
 func init() {
 	Cmd.Flags().StringP("type", "t", "", "Type of query to perform (query, query_range)")
 	Cmd.Flags().StringP("config", "c", "", "Query configuration file path")
+	Cmd.Flags().StringP("expr", "e", "", "Ad-hoc PromQL expression to run instead of query.yaml")
+	Cmd.Flags().String("time", "", "Evaluation time for instant queries: now, now-1h, RFC3339, unix or \"2006-01-02 15:04:05\" (default now)")
+	Cmd.Flags().String("start", "", "Start time for range queries, same formats as --time")
+	Cmd.Flags().String("end", "", "End time for range queries, same formats as --time (default now)")
+	Cmd.Flags().Duration("step", 0, "Step for range queries (default derived from the range, at most 11000 points)")
+	Cmd.Flags().String("timezone", "", "Timezone for absolute times and output, e.g. Asia/Shanghai, UTC, Local")
+	Cmd.Flags().StringSlice("host", nil, "Only query these host IPs")
+	Cmd.Flags().StringSlice("role", nil, "Only query hosts with these roles")
+	Cmd.Flags().Int("limit", 0, "Maximum number of series shown per result, overrides series_limit")
+	Cmd.Flags().String("export", "", "Export range query data (csv, json, openmetrics), replacing the results table when written to stdout")
+	Cmd.Flags().String("layout", "", "CSV export layout (wide, long), default wide")
+	Cmd.Flags().String("export-dir", "", "Directory for exported files, stdout when empty (only for a single host and query)")
+	Cmd.Flags().String("chart", "", "Draw range queries after the results table as a line chart (line) or sparklines (spark)")
+	Cmd.Flags().Int("chart-height", 0, "Rows of the line chart plot area (default 12)")
+	Cmd.Flags().Bool("compare", false, "Compare each query across hosts by label set, ignoring instance, and flag hosts deviating from the median")
+	Cmd.Flags().Float64("compare-percent", 0, "Flag hosts deviating from the median by more than this percentage (default 10)")
+	Cmd.Flags().Float64("compare-absolute", 0, "Flag hosts deviating from the median by more than this absolute value")
+	Cmd.Flags().StringArray("var", nil, "Template variable key=value, may be repeated; queries also use $ip, $role, ${name:regex} and $$")
+	Cmd.Flags().Bool("skip-validate", false, "Send queries without parsing them locally first (see query validate)")
 }
 
 func runQuery(cmd *cobra.Command, args []string) {
 	queryType, _ := cmd.Flags().GetString("type")
 	queryConfig, _ := cmd.Flags().GetString("config")
+	expr, _ := cmd.Flags().GetString("expr")
 
-	if err := query.LoadConfig(queryConfig); err != nil {
+	// -e 也使用 query.yaml 中的变量、时区、导出和图表配置，但不要求文件存在
+	load := query.LoadConfig
+	if expr != "" {
+		load = query.LoadOptionalConfig
+	}
+	if err := load(queryConfig); err != nil {
 		log.Error("Failed to load query config: %v", err)
 		return
 	}
-
+	if err := applyOverrides(cmd); err != nil {
+		log.Error("%v", err)
+		return
+	}
+	export := query.GetConfig().Export
+	if err := export.Validate(); err != nil {
+		log.Error("%v", err)
+		return
+	}
+	if err := query.GetConfig().Chart.Validate(); err != nil {
+		log.Error("%v", err)
+		return
+	}
+	if expr != "" {
+		runAdHoc(cmd, expr)
+		return
+	}
 	cfg := config.GetConfig()
+	if queryType == "all" || queryType == "query_range" {
+		if err := export.CheckTargets(query.RangeTargets(cfg.IPs)); err != nil {
+			log.Error("%v", err)
+			return
+		}
+	}
+	export.RedirectLogs()
+
+	if !preflight(cmd, cfg.IPs, queryType) {
+		return
+	}
+
 	manager := query.NewManager(cfg)
 	results := manager.Check(queryType)
 
+	var comparisons []query.Comparison
+	if query.GetConfig().Compare.Enabled {
+		var compareResults []checker.CheckResult
+		comparisons, compareResults = manager.Compare()
+		results = append(results, compareResults...)
+	}
+
+	// 导出到标准输出时不再打印结果表，日志已切换到标准错误
+	if export.ToStdout() {
+		return
+	}
+	output.FormatCheckResults(results)
+	formatComparisons(comparisons)
+
+	if c, ok := manager.Checker("query_range"); ok {
+		formatCharts(c.(*query.QueryRangeChecker).Charts())
+	}
+}
+
+// preflight 发送请求前在本地检查断言、模板和 PromQL，避免每个节点都等待一次失败的请求，
+// --skip-validate 只跳过模板和 PromQL 检查，返回 false 时不应发送请求
+func preflight(cmd *cobra.Command, ips []config.IPConfig, queryType string) bool {
+	skip, _ := cmd.Flags().GetBool("skip-validate")
+	var results []checker.CheckResult
+	if skip {
+		results = query.ValidateAssertions(ips, queryType)
+	} else {
+		results = query.ValidateQueries(ips, queryType)
+	}
+	for _, result := range results {
+		if result.Status == "Warning" {
+			log.Warn("%s %s on %s: %s", result.Component, result.Item, result.IP, result.Message)
+		}
+	}
+	if failed := failedResults(results); len(failed) > 0 {
+		if skip {
+			log.Error("%d queries have invalid assertions, no requests were sent", len(failed))
+		} else {
+			log.Error("%d queries are invalid, no requests were sent (use --skip-validate to skip PromQL checks)", len(failed))
+		}
+		output.FormatCheckResults(failed)
+		return false
+	}
+	return true
+}
+
+// failedResults 返回结果中失败的部分
+func failedResults(results []checker.CheckResult) []checker.CheckResult {
+	var failed []checker.CheckResult
+	for _, result := range results {
+		if result.Status == "Failed" {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// formatComparisons 以标签集合×节点的矩阵展示跨节点对比，离群值标红
+func formatComparisons(comparisons []query.Comparison) {
+	for _, comparison := range comparisons {
+		rows := make([]string, len(comparison.Rows))
+		cells := make([][]string, len(comparison.Rows))
+		failed := make([][]bool, len(comparison.Rows))
+		for i, row := range comparison.Rows {
+			rows[i] = row.Labels
+			cells[i] = []string{row.FormatMedian()}
+			failed[i] = []bool{false}
+			for _, host := range comparison.Hosts {
+				cells[i] = append(cells[i], row.FormatCell(host))
+				failed[i] = append(failed[i], row.Outliers[host])
+			}
+		}
+		cols := append([]string{"median"}, comparison.Hosts...)
+		output.FormatMatrix("Compare "+comparison.Title, "Series \\ Host", rows, cols, cells, failed)
+	}
+}
+
+// formatCharts 在结果表之后依次输出图表
+func formatCharts(charts []query.Chart) {
+	for _, chart := range charts {
+		output.FormatChart(chart.Title, chart.Lines)
+	}
+}
+
+// applyOverrides 用命令行参数覆盖 query.yaml 中的配置
+func applyOverrides(cmd *cobra.Command) error {
+	qc := query.GetConfig()
+	flags := cmd.Flags()
+
+	if tz, _ := flags.GetString("timezone"); tz != "" {
+		// LoadConfig 已按配置文件设置过时区，命令行参数优先
+		if err := query.SetTimezone(tz); err != nil {
+			return err
+		}
+		qc.Timezone = tz
+	}
+	if flags.Changed("time") {
+		qc.Query.QueryTime, _ = flags.GetString("time")
+	}
+	if flags.Changed("start") {
+		qc.QueryRange.Start, _ = flags.GetString("start")
+	}
+	if flags.Changed("end") {
+		qc.QueryRange.End, _ = flags.GetString("end")
+	}
+	if flags.Changed("step") {
+		qc.QueryRange.Step, _ = flags.GetDuration("step")
+	}
+	if limit, _ := flags.GetInt("limit"); limit > 0 {
+		qc.SeriesLimit = limit
+	}
+	if flags.Changed("export") {
+		qc.Export.Format, _ = flags.GetString("export")
+	}
+	if flags.Changed("layout") {
+		qc.Export.Layout, _ = flags.GetString("layout")
+	}
+	if flags.Changed("export-dir") {
+		qc.Export.Dir, _ = flags.GetString("export-dir")
+	}
+	qc.Chart = chartFlags(cmd, qc.Chart)
+	if flags.Changed("compare") {
+		qc.Compare.Enabled, _ = flags.GetBool("compare")
+	}
+	if flags.Changed("compare-percent") {
+		qc.Compare.Percent, _ = flags.GetFloat64("compare-percent")
+	}
+	if flags.Changed("compare-absolute") {
+		qc.Compare.Absolute, _ = flags.GetFloat64("compare-absolute")
+	}
+
+	pairs, _ := flags.GetStringArray("var")
+	vars, err := query.ParseVars(pairs)
+	if err != nil {
+		return err
+	}
+	qc.CLIVars = vars
+	return nil
+}
+
+// chartFlags 用命令行参数覆盖图表配置，未指定宽度时使用终端宽度
+func chartFlags(cmd *cobra.Command, chart query.ChartConfig) query.ChartConfig {
+	if cmd.Flags().Changed("chart") {
+		chart.Style, _ = cmd.Flags().GetString("chart")
+	}
+	if cmd.Flags().Changed("chart-height") {
+		chart.Height, _ = cmd.Flags().GetInt("chart-height")
+	}
+	if chart.Width <= 0 {
+		chart.Width = output.TerminalWidth()
+	}
+	return chart
+}
+
+// runAdHoc 执行 -e 指定的查询，导出、图表、序列数和变量使用已合并命令行参数的 query.yaml 配置
+func runAdHoc(cmd *cobra.Command, expr string) {
+	timeStr, _ := cmd.Flags().GetString("time")
+	startStr, _ := cmd.Flags().GetString("start")
+	endStr, _ := cmd.Flags().GetString("end")
+	step, _ := cmd.Flags().GetDuration("step")
+	hosts, _ := cmd.Flags().GetStringSlice("host")
+	roles, _ := cmd.Flags().GetStringSlice("role")
+
+	qc := query.GetConfig()
+	q := query.AdHocQuery{
+		Expr:        expr,
+		Step:        step,
+		SeriesLimit: qc.SeriesLimit,
+		Export:      qc.Export,
+		Chart:       qc.Chart,
+		Vars:        qc.CLIVars,
+	}
+	q.SkipValidate, _ = cmd.Flags().GetBool("skip-validate")
+
+	var err error
+	if startStr != "" || endStr != "" {
+		if startStr == "" {
+			log.Error("--start is required for a range query")
+			return
+		}
+		if q.Start, err = query.ParseTime(startStr); err != nil {
+			log.Error("Invalid --start: %v", err)
+			return
+		}
+		if q.End, err = query.ParseTime(endStr); err != nil {
+			log.Error("Invalid --end: %v", err)
+			return
+		}
+	} else if q.Time, err = query.ParseTime(timeStr); err != nil {
+		log.Error("Invalid --time: %v", err)
+		return
+	}
+
+	selected := query.SelectHosts(config.GetConfig().IPs, hosts, roles)
+	if len(selected) == 0 {
+		log.Error("No hosts match --host %v --role %v", hosts, roles)
+		return
+	}
+
+	if q.IsRange() {
+		if err := q.Export.CheckTargets(len(selected)); err != nil {
+			log.Error("%v", err)
+			return
+		}
+		q.Export.RedirectLogs()
+	}
+	results, charts := query.RunAdHoc(q, selected)
+	if q.IsRange() && q.Export.ToStdout() {
+		return
+	}
 	output.FormatCheckResults(results)
+	formatCharts(charts)
 }
@@ -0,0 +1,139 @@
+package query
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"ops_cli/internal/config"
+	"ops_cli/internal/query"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/output"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save and compare snapshots of all configured queries",
+	Long: `Capture the instant value of every query in query.yaml (query and query_range
+sections) on every host before a change, capture again afterwards and diff the
+two: series whose value changed by more than --percent or --absolute, series
+that disappeared and series that are new.`,
+}
+
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save <name> [flags]",
+	Short: "Run all configured queries as instant queries and store the results",
+	Args:  cobra.ExactArgs(1),
+	Run:   runSnapshotSave,
+}
+
+var snapshotDiffCmd = &cobra.Command{
+	Use:   "diff <a> <b> [flags]",
+	Short: "Show per-series changes, missing and new series from snapshot a to b",
+	Args:  cobra.ExactArgs(2),
+	Run:   runSnapshotDiff,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{snapshotSaveCmd, snapshotDiffCmd} {
+		c.Flags().StringP("config", "c", "", "Query configuration file path")
+	}
+	snapshotSaveCmd.Flags().String("time", "", "Evaluation time of the snapshot (default now)")
+	snapshotSaveCmd.Flags().StringSlice("host", nil, "Only query these host IPs")
+	snapshotSaveCmd.Flags().StringSlice("role", nil, "Only query hosts with these roles")
+	snapshotSaveCmd.Flags().StringArray("var", nil, "Template variable key=value, may be repeated")
+	snapshotSaveCmd.Flags().Bool("force", false, "Overwrite an existing snapshot with the same name")
+	snapshotSaveCmd.Flags().Bool("skip-validate", false, "Send queries without parsing them locally first")
+	snapshotDiffCmd.Flags().Float64("percent", 0, "Report series changed by more than this percentage (default 10)")
+	snapshotDiffCmd.Flags().Float64("absolute", 0, "Report series changed by more than this absolute value")
+
+	snapshotCmd.AddCommand(snapshotSaveCmd, snapshotDiffCmd)
+	Cmd.AddCommand(snapshotCmd)
+}
+
+func runSnapshotSave(cmd *cobra.Command, args []string) {
+	flags := cmd.Flags()
+	queryConfig, _ := flags.GetString("config")
+	if err := query.LoadConfig(queryConfig); err != nil {
+		log.Error("Failed to load query config: %v", err)
+		return
+	}
+	qc := query.GetConfig()
+
+	path, err := qc.Snapshot.Path(args[0])
+	if err != nil {
+		log.Error("%v", err)
+		return
+	}
+	// 执行查询可能耗时较长，先确认能够写入
+	force, _ := flags.GetBool("force")
+	if err := query.CheckSnapshotPath(path, force); err != nil {
+		log.Error("%v", err)
+		return
+	}
+	timeStr, _ := flags.GetString("time")
+	ts, err := query.ParseTime(timeStr)
+	if err != nil {
+		log.Error("Invalid --time: %v", err)
+		return
+	}
+	pairs, _ := flags.GetStringArray("var")
+	if qc.CLIVars, err = query.ParseVars(pairs); err != nil {
+		log.Error("%v", err)
+		return
+	}
+
+	hosts, _ := flags.GetStringSlice("host")
+	roles, _ := flags.GetStringSlice("role")
+	selected := query.SelectHosts(config.GetConfig().IPs, hosts, roles)
+	if len(selected) == 0 {
+		log.Error("No hosts match --host %v --role %v", hosts, roles)
+		return
+	}
+	if !preflight(cmd, selected, "all") {
+		return
+	}
+
+	snapshot, results := query.TakeSnapshot(args[0], ts, selected)
+	output.FormatCheckResults(results)
+
+	if err := query.SaveSnapshot(path, snapshot, force); err != nil {
+		log.Error("Failed to save snapshot: %v", err)
+		return
+	}
+	log.Info("Snapshot %s saved to %s", args[0], path)
+}
+
+func runSnapshotDiff(cmd *cobra.Command, args []string) {
+	flags := cmd.Flags()
+	queryConfig, _ := flags.GetString("config")
+	if err := query.LoadConfig(queryConfig); err != nil {
+		log.Error("Failed to load query config: %v", err)
+		return
+	}
+	cfg := query.GetConfig().Snapshot
+	if flags.Changed("percent") {
+		cfg.Percent, _ = flags.GetFloat64("percent")
+	}
+	if flags.Changed("absolute") {
+		cfg.Absolute, _ = flags.GetFloat64("absolute")
+	}
+
+	var snapshots []*query.Snapshot
+	for _, name := range args {
+		path, err := cfg.Path(name)
+		if err != nil {
+			log.Error("%v", err)
+			return
+		}
+		snapshot, err := query.LoadSnapshot(path)
+		if err != nil {
+			log.Error("Failed to load snapshot %s: %v", name, err)
+			return
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	a, b := snapshots[0], snapshots[1]
+	results := query.DiffSnapshots(cfg, a, b)
+	fmt.Printf("\nSnapshot %s (%s) -> %s (%s)\n", a.Name, query.FormatTime(a.Time), b.Name, query.FormatTime(b.Time))
+	output.FormatCheckResults(results)
+}
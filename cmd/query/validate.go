@@ -0,0 +1,56 @@
+package query
+
+import (
+	"github.com/spf13/cobra"
+	"ops_cli/internal/config"
+	"ops_cli/internal/query"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/output"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [flags]",
+	Short: "Check query templates and PromQL syntax in query.yaml without contacting Prometheus",
+	Long: `Expand every query in query.yaml for each host in config.yaml and parse it
+locally. Reports template errors, PromQL syntax errors with their position,
+unknown functions and argument types, and range vector expressions configured
+under query_range, without sending any request.`,
+	Run: runValidate,
+}
+
+func init() {
+	validateCmd.Flags().StringP("type", "t", "all", "Type of queries to validate (query, query_range, all)")
+	validateCmd.Flags().StringP("config", "c", "", "Query configuration file path")
+	validateCmd.Flags().StringArray("var", nil, "Template variable key=value, may be repeated")
+	Cmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	queryType, _ := cmd.Flags().GetString("type")
+	queryConfig, _ := cmd.Flags().GetString("config")
+
+	if err := query.LoadConfig(queryConfig); err != nil {
+		log.Error("Failed to load query config: %v", err)
+		return
+	}
+	pairs, _ := cmd.Flags().GetStringArray("var")
+	vars, err := query.ParseVars(pairs)
+	if err != nil {
+		log.Error("%v", err)
+		return
+	}
+	query.GetConfig().CLIVars = vars
+
+	results := query.ValidateQueries(config.GetConfig().IPs, queryType)
+	if len(results) == 0 {
+		log.Warn("No queries to validate for type %q", queryType)
+		return
+	}
+	output.FormatCheckResults(results)
+
+	if failed := failedResults(results); len(failed) > 0 {
+		log.Error("%d of %d queries are invalid", len(failed), len(results))
+	} else {
+		log.Info("All %d queries are valid", len(results))
+	}
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 	"ops_cli/cmd/check"
+	"ops_cli/cmd/facts"
+	"ops_cli/cmd/logs"
 	"ops_cli/cmd/query"
 	"ops_cli/internal/config"
 	"ops_cli/pkg/log"
@@ -34,4 +36,6 @@ func init() {
 
 	rootCmd.AddCommand(check.Cmd)
 	rootCmd.AddCommand(query.Cmd)
+	rootCmd.AddCommand(logs.Cmd)
+	rootCmd.AddCommand(facts.Cmd)
 }
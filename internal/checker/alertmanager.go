@@ -0,0 +1,288 @@
+package checker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"sort"
+	"strings"
+	"time"
+)
+
+type AlertmanagerChecker struct {
+	config *config.Config
+	client *http.Client
+	// replicas 按角色存储每个副本的状态，同一角色的副本视为同一个集群
+	replicas map[string]map[string]replicaStatus
+}
+
+// replicaStatus 为一个副本的配置哈希和它看到的集群成员数
+type replicaStatus struct {
+	ConfigHash string
+	Peers      int
+}
+
+type alertmanagerStatus struct {
+	Cluster struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Peers  []struct {
+			Address string `json:"address"`
+			Name    string `json:"name"`
+		} `json:"peers"`
+	} `json:"cluster"`
+	Config struct {
+		Original string `json:"original"`
+	} `json:"config"`
+	VersionInfo struct {
+		Version string `json:"version"`
+	} `json:"versionInfo"`
+}
+
+func NewAlertmanagerChecker(cfg *config.Config) *AlertmanagerChecker {
+	return &AlertmanagerChecker{
+		config: cfg,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (a *AlertmanagerChecker) Name() string {
+	return "alertmanager"
+}
+
+func (a *AlertmanagerChecker) Check() []CheckResult {
+	var results []CheckResult
+	// 每次检查重新收集副本状态
+	a.replicas = make(map[string]map[string]replicaStatus)
+
+	var roles []string
+	for _, ip := range a.config.IPs {
+		if _, ok := a.replicas[ip.Role]; !ok {
+			roles = append(roles, ip.Role)
+			a.replicas[ip.Role] = make(map[string]replicaStatus)
+		}
+		health := a.checkHealth(ip)
+		results = append(results, health)
+		if health.Status != "Passed" {
+			continue
+		}
+		results = append(results, a.checkStatus(ip), a.checkSilences(ip), a.checkAlerts(ip))
+	}
+
+	for _, role := range roles {
+		results = append(results, a.checkConsistency(role))
+	}
+
+	return results
+}
+
+func (a *AlertmanagerChecker) checkHealth(ip config.IPConfig) CheckResult {
+	log.Info("Checking Alertmanager health for %s", ip.IP)
+
+	baseUrl, err := config.GetUrl(ip.IP, ip.Role, config.ComponentAlertmanager, config.PathHealth)
+	if err != nil {
+		return a.createFailedResult("API Health", ip, "Failed to get base url", err)
+	}
+	log.Debug("Making HTTP request to %s with timeout %v", baseUrl, a.client.Timeout)
+
+	resp, err := a.client.Get(baseUrl)
+	if err != nil {
+		return a.createFailedResult("API Health", ip, "API health check failed", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return a.createFailedResult("API Health", ip, fmt.Sprintf("API returned status code %d", resp.StatusCode), nil)
+	}
+
+	result := a.createBaseResult("API Health", ip)
+	result.Status = "Passed"
+	result.Message = "API is healthy"
+	log.Info("Alertmanager health check passed for %s", ip.IP)
+
+	return result
+}
+
+func (a *AlertmanagerChecker) checkStatus(ip config.IPConfig) CheckResult {
+	log.Info("Checking Alertmanager cluster status for %s", ip.IP)
+
+	var status alertmanagerStatus
+	if err := a.getJSON(ip, config.PathStatus, "", &status); err != nil {
+		return a.createFailedResult("Cluster Status", ip, "Failed to get status", err)
+	}
+
+	sum := sha256.Sum256([]byte(status.Config.Original))
+	a.replicas[ip.Role][ip.IP] = replicaStatus{ConfigHash: hex.EncodeToString(sum[:]), Peers: len(status.Cluster.Peers)}
+
+	result := a.createBaseResult("Cluster Status", ip)
+	switch status.Cluster.Status {
+	case "ready":
+		result.Status = "Passed"
+		result.Message = fmt.Sprintf("Cluster ready with %d peers, version %s", len(status.Cluster.Peers), status.VersionInfo.Version)
+	case "disabled":
+		result.Status = "Passed"
+		result.Message = fmt.Sprintf("Clustering disabled, version %s", status.VersionInfo.Version)
+	default:
+		result.Status = "Warning"
+		result.Message = fmt.Sprintf("Cluster status %q with %d peers", status.Cluster.Status, len(status.Cluster.Peers))
+		log.Warn("Alertmanager cluster on %s is not ready: %s", ip.IP, result.Message)
+	}
+
+	return result
+}
+
+func (a *AlertmanagerChecker) checkSilences(ip config.IPConfig) CheckResult {
+	log.Info("Checking Alertmanager silences for %s", ip.IP)
+
+	var silences []struct {
+		Status struct {
+			State string `json:"state"`
+		} `json:"status"`
+	}
+	if err := a.getJSON(ip, config.PathSilences, "", &silences); err != nil {
+		return a.createFailedResult("Silences", ip, "Failed to get silences", err)
+	}
+
+	active := 0
+	for _, silence := range silences {
+		if silence.Status.State == "active" {
+			active++
+		}
+	}
+
+	result := a.createBaseResult("Silences", ip)
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("%d active silences", active)
+	return result
+}
+
+func (a *AlertmanagerChecker) checkAlerts(ip config.IPConfig) CheckResult {
+	log.Info("Checking Alertmanager alerts for %s", ip.IP)
+
+	var alerts []json.RawMessage
+	if err := a.getJSON(ip, config.PathAlerts, "active=true&silenced=false&inhibited=false", &alerts); err != nil {
+		return a.createFailedResult("Alerts", ip, "Failed to get alerts", err)
+	}
+
+	result := a.createBaseResult("Alerts", ip)
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("%d active alerts", len(alerts))
+	return result
+}
+
+// checkConsistency 比较同一角色下所有副本的配置哈希和集群成员数
+func (a *AlertmanagerChecker) checkConsistency(role string) CheckResult {
+	log.Info("Checking Alertmanager consistency between %s replicas", role)
+
+	replicas := a.replicas[role]
+	result := CheckResult{
+		Component: a.Name(),
+		Item:      "Replica Consistency",
+		Role:      role,
+	}
+
+	if len(replicas) == 0 {
+		result.Status = "Failed"
+		result.Message = "No Alertmanager replica status available"
+		log.Error("Alertmanager consistency check failed for %s: %s", role, result.Message)
+		return result
+	}
+
+	// 按配置哈希分组
+	groups := make(map[string][]string)
+	for ip, replica := range replicas {
+		groups[replica.ConfigHash] = append(groups[replica.ConfigHash], ip)
+	}
+
+	if len(groups) > 1 {
+		var parts []string
+		for hash, ips := range groups {
+			sort.Strings(ips)
+			parts = append(parts, fmt.Sprintf("%s: %s", hash[:12], strings.Join(ips, ", ")))
+		}
+		sort.Strings(parts)
+		result.Status = "Failed"
+		result.Message = fmt.Sprintf("Config differs between replicas (%s)", strings.Join(parts, "; "))
+		log.Error("Alertmanager consistency check failed for %s: %s", role, result.Message)
+		return result
+	}
+
+	// 启用集群时每个副本都应看到同一角色的全部成员
+	var mismatched []string
+	for ip, replica := range replicas {
+		if replica.Peers > 0 && replica.Peers < len(replicas) {
+			mismatched = append(mismatched, fmt.Sprintf("%s (%d peers)", ip, replica.Peers))
+		}
+	}
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		result.Status = "Warning"
+		result.Message = fmt.Sprintf("Replicas see fewer peers than expected: %s", strings.Join(mismatched, ", "))
+		log.Warn("Alertmanager consistency check for %s: %s", role, result.Message)
+		return result
+	}
+
+	var hash string
+	for h := range groups {
+		hash = h
+	}
+
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("%d replicas share config hash %s", len(replicas), hash[:12])
+	log.Info("Alertmanager consistency check passed for %s", role)
+	return result
+}
+
+func (a *AlertmanagerChecker) getJSON(ip config.IPConfig, item string, rawQuery string, v interface{}) error {
+	baseUrl, err := config.GetUrl(ip.IP, ip.Role, config.ComponentAlertmanager, item)
+	if err != nil {
+		return err
+	}
+	if rawQuery != "" {
+		baseUrl = baseUrl + "?" + rawQuery
+	}
+	log.Debug("Fetching %s", baseUrl)
+
+	resp, err := a.client.Get(baseUrl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse JSON response: %v", err)
+	}
+	return nil
+}
+
+func (a *AlertmanagerChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
+	return CheckResult{
+		Component: a.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+}
+
+func (a *AlertmanagerChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := a.createBaseResult(item, ip)
+	result.Status = "Failed"
+	result.Message = message
+	result.Error = err
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
@@ -0,0 +1,228 @@
+package checker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/ssh"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 未配置时的默认证书过期窗口（天）
+const (
+	defaultCertWarningDays  = 30
+	defaultCertCriticalDays = 7
+)
+
+type CertificateChecker struct {
+	config *config.Config
+	dialer *net.Dialer
+}
+
+func NewCertificateChecker(cfg *config.Config) *CertificateChecker {
+	return &CertificateChecker{
+		config: cfg,
+		dialer: &net.Dialer{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *CertificateChecker) Name() string {
+	return "certificate"
+}
+
+func (c *CertificateChecker) Check() []CheckResult {
+	var results []CheckResult
+
+	for _, ip := range c.config.IPs {
+		for _, endpoint := range c.config.Certificates.Endpoints {
+			if endpoint.AppliesTo(ip.Role) {
+				results = append(results, c.checkEndpoint(ip, endpoint))
+			}
+		}
+		results = append(results, c.checkFiles(ip)...)
+	}
+
+	return results
+}
+
+func (c *CertificateChecker) windows() (int, int) {
+	warning, critical := c.config.Certificates.WarningDays, c.config.Certificates.CriticalDays
+	if warning <= 0 {
+		warning = defaultCertWarningDays
+	}
+	if critical <= 0 {
+		critical = defaultCertCriticalDays
+	}
+	return warning, critical
+}
+
+func (c *CertificateChecker) checkEndpoint(ip config.IPConfig, endpoint config.CertEndpointConfig) CheckResult {
+	item := "TLS " + endpoint.Name
+
+	address := expandIP(endpoint.Address, ip.IP)
+	if address == "" {
+		port, err := config.GetPort(ip.Role, endpoint.Component)
+		if err != nil {
+			return c.createFailedResult(item, ip, "Failed to get endpoint port", err)
+		}
+		address = net.JoinHostPort(ip.IP, strconv.Itoa(port))
+	}
+
+	log.Info("Checking TLS certificate of %s (%s) for %s", endpoint.Name, address, ip.IP)
+
+	// 跳过链校验以便检查已过期或自签名的证书，到期和主机名由下面单独判断，
+	// SNI 只使用配置的 server_name
+	conn, err := tls.DialWithDialer(c.dialer, "tcp", address, &tls.Config{
+		ServerName:         endpoint.ServerName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return c.createFailedResult(item, ip, fmt.Sprintf("TLS handshake with %s failed", address), err)
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return c.createFailedResult(item, ip, fmt.Sprintf("%s presented no certificate", address), nil)
+	}
+
+	// 未配置 server_name 时校验地址中的主机部分
+	hostname := endpoint.ServerName
+	if hostname == "" {
+		hostname, _, _ = net.SplitHostPort(address)
+	}
+	return c.evaluateCertificate(item, ip, certs[0], hostname)
+}
+
+func (c *CertificateChecker) checkFiles(ip config.IPConfig) []CheckResult {
+	var files []config.CertFileConfig
+	for _, file := range c.config.Certificates.Files {
+		if file.AppliesTo(ip.Role) {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	client := ssh.NewClient(ip.IP, ip.User, ip.Password, ip.Port)
+	if err := client.Connect(); err != nil {
+		return []CheckResult{c.createFailedResult("Certificate Files", ip, "Failed to establish SSH connection", err)}
+	}
+	defer client.Close()
+
+	var results []CheckResult
+	for _, file := range files {
+		results = append(results, c.checkFile(client, ip, file))
+	}
+	return results
+}
+
+func (c *CertificateChecker) checkFile(client *ssh.Client, ip config.IPConfig, file config.CertFileConfig) CheckResult {
+	item := "File " + file.Path
+	log.Info("Checking certificate file %s on %s", file.Path, ip.IP)
+
+	output, err := client.RunCommand(fmt.Sprintf("cat '%s'", file.Path))
+	if err != nil {
+		return c.createFailedResult(item, ip, "Failed to read certificate file", err)
+	}
+
+	cert, err := parsePEMCertificate([]byte(output))
+	if err != nil {
+		return c.createFailedResult(item, ip, "Failed to parse certificate", err)
+	}
+
+	return c.evaluateCertificate(item, ip, cert, file.Hostname)
+}
+
+// evaluateCertificate 根据到期时间和主机名生成检查结果，两者都有问题时一起报告，hostname 为空时不校验主机名
+func (c *CertificateChecker) evaluateCertificate(item string, ip config.IPConfig, cert *x509.Certificate, hostname string) CheckResult {
+	warning, critical := c.windows()
+	daysLeft := int(time.Until(cert.NotAfter).Hours() / 24)
+
+	summary := fmt.Sprintf("subject %q, SANs [%s], issuer %q, expires %s (%d days)",
+		cert.Subject.CommonName, strings.Join(certificateSANs(cert), ", "), cert.Issuer.CommonName,
+		cert.NotAfter.Format("2006-01-02"), daysLeft)
+
+	result := c.createBaseResult(item, ip)
+	result.Status = "Passed"
+	var problems []string
+	switch {
+	case time.Now().After(cert.NotAfter):
+		result.Status = "Failed"
+		problems = append(problems, "Certificate expired")
+	case daysLeft <= critical:
+		result.Status = "Failed"
+		problems = append(problems, "Certificate expires soon")
+	case daysLeft <= warning:
+		result.Status = "Warning"
+		problems = append(problems, "Certificate expires soon")
+	}
+	if hostname != "" {
+		if err := cert.VerifyHostname(hostname); err != nil {
+			result.Status = "Failed"
+			problems = append(problems, fmt.Sprintf("hostname %s mismatch", hostname))
+		}
+	}
+
+	if len(problems) == 0 {
+		result.Message = summary
+		log.Info("%s check passed for %s", item, ip.IP)
+		return result
+	}
+
+	problems[0] = strings.ToUpper(problems[0][:1]) + problems[0][1:]
+	result.Message = strings.Join(problems, ", ") + ": " + summary
+	if result.Status == "Failed" {
+		log.Error("%s check failed for %s: %s", item, ip.IP, result.Message)
+	} else {
+		log.Warn("%s on %s: %s", item, ip.IP, result.Message)
+	}
+	return result
+}
+
+func certificateSANs(cert *x509.Certificate) []string {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, addr := range cert.IPAddresses {
+		sans = append(sans, addr.String())
+	}
+	return sans
+}
+
+// parsePEMCertificate 返回 PEM 数据中的第一个证书
+func parsePEMCertificate(data []byte) (*x509.Certificate, error) {
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+		data = rest
+	}
+}
+
+func (c *CertificateChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
+	return CheckResult{
+		Component: c.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+}
+
+func (c *CertificateChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := c.createBaseResult(item, ip)
+	result.Status = "Failed"
+	result.Message = message
+	result.Error = err
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
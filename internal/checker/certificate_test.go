@@ -0,0 +1,70 @@
+package checker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"ops_cli/internal/config"
+)
+
+func testCertificate(t *testing.T, notAfter time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "grafana.example.com"},
+		DNSNames:     []string{"grafana.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestEvaluateCertificate(t *testing.T) {
+	c := NewCertificateChecker(&config.Config{})
+	ip := config.IPConfig{IP: "10.0.0.1", Role: "fp"}
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		notAfter time.Time
+		hostname string
+		status   string
+		message  string
+	}{
+		{"valid", now.Add(90 * 24 * time.Hour), "grafana.example.com", "Passed", `subject "grafana.example.com"`},
+		{"valid ip", now.Add(90 * 24 * time.Hour), "10.0.0.1", "Passed", `subject "grafana.example.com"`},
+		{"no hostname", now.Add(90 * 24 * time.Hour), "", "Passed", `subject "grafana.example.com"`},
+		{"warning", now.Add(20 * 24 * time.Hour), "grafana.example.com", "Warning", "Certificate expires soon: "},
+		{"critical", now.Add(3 * 24 * time.Hour), "grafana.example.com", "Failed", "Certificate expires soon: "},
+		{"mismatch", now.Add(90 * 24 * time.Hour), "10.0.0.2", "Failed", "Hostname 10.0.0.2 mismatch: "},
+		// 到期和主机名都有问题时一起报告
+		{"expired and mismatch", now.Add(-24 * time.Hour), "other.example.com", "Failed", "Certificate expired, hostname other.example.com mismatch: "},
+		{"warning and mismatch", now.Add(20 * 24 * time.Hour), "other.example.com", "Failed", "Certificate expires soon, hostname other.example.com mismatch: "},
+	}
+	for _, tt := range tests {
+		result := c.evaluateCertificate("TLS grafana", ip, testCertificate(t, tt.notAfter), tt.hostname)
+		if result.Status != tt.status || !strings.HasPrefix(result.Message, tt.message) {
+			t.Errorf("%s: got %s %q, want %s with prefix %q", tt.name, result.Status, result.Message, tt.status, tt.message)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package checker
+
+import (
+	"fmt"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/diff"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/ssh"
+	"sort"
+	"strings"
+)
+
+// 单个文件差异在结果中展示的最大行数
+const maxDriftDiffLines = 200
+
+type DriftChecker struct {
+	config *config.Config
+}
+
+// hostChecksums 为一个节点上收集到的文件校验和
+type hostChecksums struct {
+	Sums map[string]string
+	// Unreadable 为因权限等原因无法读取的文件或目录，其中的文件不参与对比
+	Unreadable []string
+	// Errors 为 find 和 sha256sum 输出的错误，不包括不存在的路径
+	Errors []string
+}
+
+// unreadable 判断文件是否位于无法读取的路径下
+func (h hostChecksums) unreadable(file string) bool {
+	for _, path := range h.Unreadable {
+		if file == path || strings.HasPrefix(file, strings.TrimSuffix(path, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// checksumGroup 表示内容相同（或都缺失）的一组节点
+type checksumGroup struct {
+	Hash  string
+	Hosts []config.IPConfig
+}
+
+func NewDriftChecker(cfg *config.Config) *DriftChecker {
+	return &DriftChecker{
+		config: cfg,
+	}
+}
+
+func (d *DriftChecker) Name() string {
+	return "drift"
+}
+
+func (d *DriftChecker) Check() []CheckResult {
+	var results []CheckResult
+
+	// 按角色分组，保持配置中的顺序
+	var roles []string
+	hostsByRole := make(map[string][]config.IPConfig)
+	for _, ip := range d.config.IPs {
+		if _, ok := hostsByRole[ip.Role]; !ok {
+			roles = append(roles, ip.Role)
+		}
+		hostsByRole[ip.Role] = append(hostsByRole[ip.Role], ip)
+	}
+
+	for _, role := range roles {
+		// viper 会把 map 的键转为小写
+		paths := d.config.Drift.Paths[strings.ToLower(role)]
+		if len(paths) == 0 {
+			continue
+		}
+		results = append(results, d.checkRole(role, hostsByRole[role], paths)...)
+	}
+
+	return results
+}
+
+func (d *DriftChecker) checkRole(role string, hosts []config.IPConfig, paths []string) []CheckResult {
+	log.Info("Checking configuration drift for role %s", role)
+
+	var results []CheckResult
+	var reachable []config.IPConfig
+	checksums := make(map[string]hostChecksums)
+
+	for _, ip := range hosts {
+		sums, err := d.collectChecksums(ip, paths)
+		if err != nil {
+			results = append(results, d.createFailedResult("Checksums", ip, "Failed to collect checksums", err))
+			continue
+		}
+		if len(sums.Errors) > 0 {
+			result := CheckResult{
+				Component: d.Name(),
+				Item:      "Checksums",
+				Role:      ip.Role,
+				IP:        ip.IP,
+				Status:    "Warning",
+				Message:   fmt.Sprintf("%d paths could not be read and are excluded from comparison", len(sums.Errors)),
+				Details:   sums.Errors,
+			}
+			log.Warn("Checksums on %s: %s", ip.IP, result.Message)
+			results = append(results, result)
+		}
+		reachable = append(reachable, ip)
+		checksums[ip.IP] = sums
+	}
+
+	summary := CheckResult{Component: d.Name(), Item: "Drift Summary", Role: role}
+	if len(reachable) < 2 {
+		summary.Status = "Passed"
+		summary.Message = fmt.Sprintf("%d reachable host(s), nothing to compare", len(reachable))
+		return append(results, summary)
+	}
+
+	// 所有节点上出现过的文件
+	fileSet := make(map[string]bool)
+	for _, sums := range checksums {
+		for file := range sums.Sums {
+			fileSet[file] = true
+		}
+	}
+	files := make([]string, 0, len(fileSet))
+	for file := range fileSet {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	fetcher := newFileFetcher()
+	defer fetcher.close()
+
+	drifted := 0
+	for _, file := range files {
+		groups := groupByChecksum(reachable, checksums, file)
+		if len(groups) <= 1 {
+			continue
+		}
+		drifted++
+		results = append(results, d.reportDrift(fetcher, role, file, groups))
+	}
+
+	if drifted > 0 {
+		summary.Status = "Failed"
+		summary.Message = fmt.Sprintf("%d of %d files drifted across %d hosts", drifted, len(files), len(reachable))
+		log.Error("Configuration drift found for role %s: %s", role, summary.Message)
+	} else {
+		summary.Status = "Passed"
+		summary.Message = fmt.Sprintf("%d files identical across %d hosts", len(files), len(reachable))
+		log.Info("No configuration drift for role %s", role)
+	}
+
+	return append(results, summary)
+}
+
+func (d *DriftChecker) reportDrift(fetcher *fileFetcher, role, file string, groups []checksumGroup) CheckResult {
+	majority := groups[0]
+
+	var outlierIPs, parts []string
+	for _, group := range groups[1:] {
+		for _, host := range group.Hosts {
+			outlierIPs = append(outlierIPs, host.IP)
+			parts = append(parts, fmt.Sprintf("%s (%s)", host.IP, shortHash(group.Hash)))
+		}
+	}
+
+	result := CheckResult{
+		Component: d.Name(),
+		Item:      "Drift " + file,
+		Role:      role,
+		IP:        strings.Join(outlierIPs, ", "),
+		Status:    "Failed",
+		Message: fmt.Sprintf("Outliers %s differ from majority of %d hosts (%s)",
+			strings.Join(parts, ", "), len(majority.Hosts), shortHash(majority.Hash)),
+	}
+	log.Error("Drift in %s for role %s: %s", file, role, result.Message)
+
+	if d.config.Drift.Diff {
+		for _, group := range groups[1:] {
+			for _, host := range group.Hosts {
+				result.Details = append(result.Details, d.diffFile(fetcher, file, majority.Hosts[0], host)...)
+			}
+		}
+	}
+
+	return result
+}
+
+// diffFile 拉取多数版本和离群节点上的文件并生成统一差异
+func (d *DriftChecker) diffFile(fetcher *fileFetcher, file string, reference, outlier config.IPConfig) []string {
+	want, err := fetcher.fetch(reference, file)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to fetch %s from %s: %v", file, reference.IP, err)}
+	}
+	got, err := fetcher.fetch(outlier, file)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to fetch %s from %s: %v", file, outlier.IP, err)}
+	}
+
+	lines := diff.Unified(reference.IP+":"+file, outlier.IP+":"+file, diff.Lines(want), diff.Lines(got), 3)
+	if len(lines) > maxDriftDiffLines {
+		lines = append(lines[:maxDriftDiffLines], fmt.Sprintf("... %d more lines", len(lines)-maxDriftDiffLines))
+	}
+	return lines
+}
+
+// collectChecksums 返回配置路径下所有文件的 sha256，不存在的路径按缺失处理，
+// 其他错误记录在 Errors 中
+func (d *DriftChecker) collectChecksums(ip config.IPConfig, paths []string) (hostChecksums, error) {
+	client := ssh.NewClient(ip.IP, ip.User, ip.Password, ip.Port)
+	if err := client.Connect(); err != nil {
+		return hostChecksums{}, err
+	}
+	defer client.Close()
+
+	// 路径不加引号以便远端 shell 展开通配符，错误输出与结果合并后按前缀区分
+	output, err := client.RunCommand(fmt.Sprintf("LC_ALL=C find %s -type f -exec sha256sum {} + 2>&1; true", strings.Join(paths, " ")))
+	if err != nil {
+		return hostChecksums{}, err
+	}
+	return parseChecksums(output), nil
+}
+
+// parseChecksums 解析 sha256sum 的输出和 find、sha256sum 的错误
+func parseChecksums(output string) hostChecksums {
+	sums := hostChecksums{Sums: make(map[string]string)}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if prefix, rest, ok := strings.Cut(line, ": "); ok && (prefix == "find" || prefix == "sha256sum") {
+			if strings.HasSuffix(rest, "No such file or directory") && prefix == "find" {
+				continue
+			}
+			sums.Errors = append(sums.Errors, line)
+			if i := strings.LastIndex(rest, ": "); i > 0 {
+				sums.Unreadable = append(sums.Unreadable, strings.Trim(rest[:i], "'‘’"))
+			}
+			continue
+		}
+		hash, file, ok := strings.Cut(line, "  ")
+		if !ok {
+			continue
+		}
+		sums.Sums[file] = hash
+	}
+	return sums
+}
+
+// fileFetcher 复用每个节点的 SSH 连接读取文件，并缓存读取过的内容
+type fileFetcher struct {
+	clients  map[string]*ssh.Client
+	contents map[string]string
+}
+
+func newFileFetcher() *fileFetcher {
+	return &fileFetcher{
+		clients:  make(map[string]*ssh.Client),
+		contents: make(map[string]string),
+	}
+}
+
+// fetch 读取节点上的文件内容，文件不存在时返回空内容
+func (f *fileFetcher) fetch(ip config.IPConfig, file string) (string, error) {
+	key := ip.IP + " " + file
+	if content, ok := f.contents[key]; ok {
+		return content, nil
+	}
+
+	client, ok := f.clients[ip.IP]
+	if !ok {
+		client = ssh.NewClient(ip.IP, ip.User, ip.Password, ip.Port)
+		if err := client.Connect(); err != nil {
+			return "", err
+		}
+		f.clients[ip.IP] = client
+	}
+
+	content, err := client.RunCommand(fmt.Sprintf("if [ -e '%s' ]; then cat '%s'; fi", file, file))
+	if err != nil {
+		return "", err
+	}
+	f.contents[key] = content
+	return content, nil
+}
+
+func (f *fileFetcher) close() {
+	for _, client := range f.clients {
+		client.Close()
+	}
+}
+
+// groupByChecksum 按校验和对节点分组，第一个分组为多数版本，无法读取该文件的节点不参与分组
+func groupByChecksum(hosts []config.IPConfig, checksums map[string]hostChecksums, file string) []checksumGroup {
+	var groups []checksumGroup
+	index := make(map[string]int)
+
+	for _, host := range hosts {
+		hash, ok := checksums[host.IP].Sums[file] // 缺失的文件校验和为空
+		if !ok && checksums[host.IP].unreadable(file) {
+			continue
+		}
+		i, ok := index[hash]
+		if !ok {
+			i = len(groups)
+			index[hash] = i
+			groups = append(groups, checksumGroup{Hash: hash})
+		}
+		groups[i].Hosts = append(groups[i].Hosts, host)
+	}
+
+	// 节点数相同时保持首次出现的顺序
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i].Hosts) > len(groups[j].Hosts)
+	})
+	return groups
+}
+
+func shortHash(hash string) string {
+	if hash == "" {
+		return "missing"
+	}
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func (d *DriftChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := CheckResult{
+		Component: d.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+		Status:    "Failed",
+		Message:   message,
+		Error:     err,
+	}
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
@@ -0,0 +1,81 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+
+	"ops_cli/internal/config"
+)
+
+func TestParseChecksums(t *testing.T) {
+	output := `3f2a0c8d9e1b4a5f6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b  /etc/prometheus/prometheus.yml
+0000000000000000000000000000000000000000000000000000000000000000  /etc/prometheus/rules/my rules.yml
+find: '/etc/prometheus/secret': Permission denied
+find: '/etc/missing': No such file or directory
+sha256sum: /etc/prometheus/key.pem: Permission denied
+`
+	got := parseChecksums(output)
+
+	wantSums := map[string]string{
+		"/etc/prometheus/prometheus.yml":     "3f2a0c8d9e1b4a5f6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b",
+		"/etc/prometheus/rules/my rules.yml": "0000000000000000000000000000000000000000000000000000000000000000",
+	}
+	if !reflect.DeepEqual(got.Sums, wantSums) {
+		t.Errorf("Sums = %v, want %v", got.Sums, wantSums)
+	}
+	// 不存在的路径按缺失处理，不算错误
+	if want := []string{"/etc/prometheus/secret", "/etc/prometheus/key.pem"}; !reflect.DeepEqual(got.Unreadable, want) {
+		t.Errorf("Unreadable = %q, want %q", got.Unreadable, want)
+	}
+	if len(got.Errors) != 2 {
+		t.Errorf("Errors = %q, want 2 lines", got.Errors)
+	}
+
+	if !got.unreadable("/etc/prometheus/secret/token") || !got.unreadable("/etc/prometheus/key.pem") || got.unreadable("/etc/prometheus/secrets.yml") {
+		t.Errorf("unreadable() does not match paths under %q", got.Unreadable)
+	}
+
+	if empty := parseChecksums(""); len(empty.Sums) != 0 || len(empty.Errors) != 0 {
+		t.Errorf("parseChecksums of empty output = %+v", empty)
+	}
+}
+
+func TestGroupByChecksum(t *testing.T) {
+	a := config.IPConfig{IP: "10.0.0.1", Role: "fp"}
+	b := config.IPConfig{IP: "10.0.0.2", Role: "fp"}
+	c := config.IPConfig{IP: "10.0.0.3", Role: "fp"}
+	d := config.IPConfig{IP: "10.0.0.4", Role: "fp"}
+	hosts := []config.IPConfig{a, b, c, d}
+
+	file := "/etc/prometheus/prometheus.yml"
+	checksums := map[string]hostChecksums{
+		a.IP: {Sums: map[string]string{file: "old"}},
+		b.IP: {Sums: map[string]string{file: "new"}},
+		c.IP: {Sums: map[string]string{file: "new"}},
+		d.IP: {Sums: map[string]string{}},
+	}
+
+	hashes := func(groups []checksumGroup) []string {
+		var out []string
+		for _, group := range groups {
+			out = append(out, group.Hash)
+		}
+		return out
+	}
+
+	// 多数版本在前，节点数相同时按首次出现顺序，缺失的文件单独成组
+	groups := groupByChecksum(hosts, checksums, file)
+	if got, want := hashes(groups), []string{"new", "old", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %q, want %q", got, want)
+	}
+	if len(groups[0].Hosts) != 2 || groups[0].Hosts[0].IP != b.IP {
+		t.Errorf("majority group = %+v", groups[0])
+	}
+
+	// 无法读取的节点不参与分组
+	checksums[d.IP] = hostChecksums{Sums: map[string]string{}, Unreadable: []string{"/etc/prometheus"}}
+	groups = groupByChecksum(hosts, checksums, file)
+	if got, want := hashes(groups), []string{"new", "old"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups with unreadable host = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,162 @@
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/metrics"
+	"strings"
+	"time"
+)
+
+type ExporterChecker struct {
+	config *config.Config
+	client *http.Client
+}
+
+func NewExporterChecker(cfg *config.Config) *ExporterChecker {
+	return &ExporterChecker{
+		config: cfg,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (e *ExporterChecker) Name() string {
+	return "exporter"
+}
+
+func (e *ExporterChecker) Check() []CheckResult {
+	var results []CheckResult
+
+	for _, ip := range e.config.IPs {
+		for _, exporter := range e.config.Exporters {
+			if !exporter.AppliesTo(ip.Role) {
+				continue
+			}
+			results = append(results, e.checkExporter(ip, exporter)...)
+		}
+	}
+
+	return results
+}
+
+func (e *ExporterChecker) checkExporter(ip config.IPConfig, exporter config.ExporterConfig) []CheckResult {
+	log.Info("Scraping %s on %s", exporter.Name, ip.IP)
+
+	path := exporter.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	url := fmt.Sprintf("http://%s:%d%s", ip.IP, exporter.Port, path)
+	log.Debug("Making HTTP request to %s with timeout %v", url, e.client.Timeout)
+
+	scrapeItem := exporter.Name + " Scrape"
+
+	start := time.Now()
+	resp, err := e.client.Get(url)
+	if err != nil {
+		return []CheckResult{e.createFailedResult(scrapeItem, ip, "Scrape failed", err)}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	latency := time.Since(start)
+	if err != nil {
+		return []CheckResult{e.createFailedResult(scrapeItem, ip, "Failed to read response body", err)}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return []CheckResult{e.createFailedResult(scrapeItem, ip, fmt.Sprintf("Exporter returned status code %d", resp.StatusCode), nil)}
+	}
+
+	families, err := metrics.Parse(bytes.NewReader(body))
+	if err != nil {
+		return []CheckResult{e.createFailedResult(scrapeItem, ip, "Failed to parse exposition format", err)}
+	}
+
+	scrape := e.createBaseResult(scrapeItem, ip)
+	scrape.Status = "Passed"
+	scrape.Message = fmt.Sprintf("%d metric families, %d bytes in %s", len(families), len(body), latency.Round(time.Millisecond))
+	log.Info("Scrape of %s on %s succeeded: %s", exporter.Name, ip.IP, scrape.Message)
+
+	return []CheckResult{
+		scrape,
+		e.checkMetrics(ip, exporter, families),
+		e.checkVersion(ip, exporter, families),
+	}
+}
+
+func (e *ExporterChecker) checkMetrics(ip config.IPConfig, exporter config.ExporterConfig, families map[string]*metrics.Family) CheckResult {
+	item := exporter.Name + " Metrics"
+
+	var missing []string
+	for _, name := range exporter.Metrics {
+		if f, ok := families[name]; !ok || len(f.Samples) == 0 {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return e.createFailedResult(item, ip, fmt.Sprintf("Missing metrics: %s", strings.Join(missing, ", ")), nil)
+	}
+
+	result := e.createBaseResult(item, ip)
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("All %d expected metrics present", len(exporter.Metrics))
+	return result
+}
+
+func (e *ExporterChecker) checkVersion(ip config.IPConfig, exporter config.ExporterConfig, families map[string]*metrics.Family) CheckResult {
+	item := exporter.Name + " Version"
+
+	buildInfo := exporter.BuildInfo
+	if buildInfo == "" {
+		buildInfo = exporter.Name + "_build_info"
+	}
+
+	family, ok := families[buildInfo]
+	if !ok || len(family.Samples) == 0 {
+		result := e.createBaseResult(item, ip)
+		result.Status = "Warning"
+		result.Message = fmt.Sprintf("Metric %s not found", buildInfo)
+		log.Warn("%s on %s: %s", item, ip.IP, result.Message)
+		return result
+	}
+	version := family.Samples[0].Labels["version"]
+
+	// viper 会把 map 的键转为小写
+	expected, ok := exporter.Versions[strings.ToLower(ip.Role)]
+	if !ok {
+		expected = exporter.Versions["default"]
+	}
+
+	if expected != "" && version != expected {
+		return e.createFailedResult(item, ip, fmt.Sprintf("Version %s, expected %s", version, expected), nil)
+	}
+
+	result := e.createBaseResult(item, ip)
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("Version %s", version)
+	return result
+}
+
+func (e *ExporterChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
+	return CheckResult{
+		Component: e.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+}
+
+func (e *ExporterChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := e.createBaseResult(item, ip)
+	result.Status = "Failed"
+	result.Message = message
+	result.Error = err
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
@@ -0,0 +1,221 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type HTTPChecker struct {
+	config *config.Config
+}
+
+func NewHTTPChecker(cfg *config.Config) *HTTPChecker {
+	return &HTTPChecker{
+		config: cfg,
+	}
+}
+
+func (h *HTTPChecker) Name() string {
+	return "http"
+}
+
+// httpCheck 为校验过的 HTTP 检查配置
+type httpCheck struct {
+	config.HTTPCheckConfig
+	method    string
+	bodyRegex *regexp.Regexp
+}
+
+// newHTTPCheck 在发送请求前校验 method、url 和 body_regex，配置有误时每个检查只报告一次
+func newHTTPCheck(check config.HTTPCheckConfig) (httpCheck, error) {
+	c := httpCheck{HTTPCheckConfig: check, method: strings.ToUpper(check.Method)}
+	if c.method == "" {
+		c.method = http.MethodGet
+	}
+
+	// 用占位 IP 展开后再解析，同时校验 method 是否合法
+	req, err := http.NewRequest(c.method, expandIP(check.URL, "127.0.0.1"), nil)
+	if err != nil {
+		return c, fmt.Errorf("invalid method or url: %v", err)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" || req.URL.Host == "" {
+		return c, fmt.Errorf("url %q must be an absolute http or https URL", check.URL)
+	}
+
+	if check.BodyRegex != "" {
+		if c.bodyRegex, err = regexp.Compile(check.BodyRegex); err != nil {
+			return c, fmt.Errorf("invalid body_regex: %v", err)
+		}
+	}
+	return c, nil
+}
+
+func (h *HTTPChecker) Check() []CheckResult {
+	var results []CheckResult
+
+	var checks []httpCheck
+	for _, check := range h.config.HTTPChecks {
+		c, err := newHTTPCheck(check)
+		if err != nil {
+			results = append(results, CheckResult{
+				Component: h.Name(),
+				Item:      check.Name,
+				Status:    "Failed",
+				Message:   "Invalid check config",
+				Error:     err,
+			})
+			log.Error("HTTP check %s has invalid config: %v", check.Name, err)
+			continue
+		}
+		checks = append(checks, c)
+	}
+
+	for _, ip := range h.config.IPs {
+		for _, check := range checks {
+			if !check.AppliesTo(ip.Role) {
+				continue
+			}
+			results = append(results, h.checkEndpoint(ip, check))
+		}
+	}
+
+	return results
+}
+
+func (h *HTTPChecker) checkEndpoint(ip config.IPConfig, check httpCheck) CheckResult {
+	url := expandIP(check.URL, ip.IP)
+	log.Info("Checking HTTP endpoint %s (%s) for %s", check.Name, url, ip.IP)
+
+	expectedStatus := check.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	req, err := http.NewRequest(check.method, url, strings.NewReader(check.Body))
+	if err != nil {
+		return h.createFailedResult(check.Name, ip, "Failed to build request", err)
+	}
+	for k, v := range check.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	log.Debug("Making HTTP %s request to %s with timeout %v", req.Method, url, timeout)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return h.createFailedResult(check.Name, ip, "HTTP request failed", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	latency := time.Since(start)
+	if err != nil {
+		return h.createFailedResult(check.Name, ip, "Failed to read response body", err)
+	}
+	log.Debug("Response from %s - Status: %d, Latency: %v", url, resp.StatusCode, latency)
+
+	if resp.StatusCode != expectedStatus {
+		return h.createFailedResult(check.Name, ip, fmt.Sprintf("Status code %d, expected %d", resp.StatusCode, expectedStatus), nil)
+	}
+
+	if check.bodyRegex != nil && !check.bodyRegex.Match(body) {
+		return h.createFailedResult(check.Name, ip, fmt.Sprintf("Body does not match %q", check.BodyRegex), nil)
+	}
+
+	if check.JSONPath != "" {
+		value, err := lookupJSONPath(body, check.JSONPath)
+		if err != nil {
+			return h.createFailedResult(check.Name, ip, "JSON path assertion failed", err)
+		}
+		if check.JSONValue != "" && value != check.JSONValue {
+			return h.createFailedResult(check.Name, ip, fmt.Sprintf("%s is %q, expected %q", check.JSONPath, value, check.JSONValue), nil)
+		}
+	}
+
+	if check.MaxLatency > 0 && latency > check.MaxLatency {
+		return h.createFailedResult(check.Name, ip, fmt.Sprintf("Latency %s exceeds %s", latency.Round(time.Millisecond), check.MaxLatency), nil)
+	}
+
+	result := h.createBaseResult(check.Name, ip)
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("Status %d in %s", resp.StatusCode, latency.Round(time.Millisecond))
+	log.Info("HTTP check %s passed for %s", check.Name, ip.IP)
+
+	return result
+}
+
+// lookupJSONPath 按点分隔路径取值，数字段作为数组下标
+func lookupJSONPath(body []byte, path string) (string, error) {
+	var current interface{}
+	if err := json.Unmarshal(body, &current); err != nil {
+		return "", fmt.Errorf("failed to parse JSON response: %v", err)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return "", fmt.Errorf("field %q not found", key)
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", fmt.Errorf("invalid array index %q", key)
+			}
+			current = node[index]
+		default:
+			return "", fmt.Errorf("cannot descend into %q", key)
+		}
+	}
+
+	switch v := current.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "null", nil
+	case map[string]interface{}, []interface{}:
+		out, _ := json.Marshal(v)
+		return string(out), nil
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+// expandIP 替换模板中的 {ip} 占位符
+func expandIP(template string, ip string) string {
+	return strings.ReplaceAll(template, "{ip}", ip)
+}
+
+func (h *HTTPChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
+	return CheckResult{
+		Component: h.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+}
+
+func (h *HTTPChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := h.createBaseResult(item, ip)
+	result.Status = "Failed"
+	result.Message = message
+	result.Error = err
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
@@ -0,0 +1,149 @@
+package checker
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ops_cli/internal/config"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	body := []byte(`{"status":"up","data":{"count":3,"ratio":0.5,"ok":true,"none":null,
+		"items":[{"name":"a"},{"name":"b","tags":["x","y"]}],"nested":{"k":"v"}}}`)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"status", "up", false},
+		{"data.count", "3", false},
+		{"data.ratio", "0.5", false},
+		{"data.ok", "true", false},
+		{"data.none", "null", false},
+		{"data.items.1.name", "b", false},
+		{"data.items.1.tags", `["x","y"]`, false},
+		{"data.nested", `{"k":"v"}`, false},
+		{"data.missing", "", true},
+		{"data.items.2", "", true},
+		{"data.items.name", "", true},
+		{"status.value", "", true},
+	}
+	for _, tt := range tests {
+		got, err := lookupJSONPath(body, tt.path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("lookupJSONPath(%q) = %q, %v, want %q (error %v)", tt.path, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	if _, err := lookupJSONPath([]byte("not json"), "status"); err == nil {
+		t.Error("Expected an error for a non-JSON body")
+	}
+}
+
+func TestHTTPChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			fmt.Fprint(w, `{"status":"up","version":"2.1"}`)
+		case "/post":
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		IPs: []config.IPConfig{{IP: "10.0.0.1", Role: "fp"}, {IP: "10.0.0.2", Role: "fp"}},
+		HTTPChecks: []config.HTTPCheckConfig{
+			{Name: "health", URL: server.URL + "/health", BodyRegex: `"status":"up"`, JSONPath: "version", JSONValue: "2.1"},
+			{Name: "post", URL: server.URL + "/post", Method: "post"},
+			{Name: "regex", URL: server.URL + "/health", BodyRegex: `down`},
+			{Name: "json", URL: server.URL + "/health", JSONPath: "version", JSONValue: "3.0"},
+			{Name: "status", URL: server.URL + "/missing"},
+			{Name: "bad regex", URL: server.URL + "/health", BodyRegex: `(`},
+			{Name: "bad method", URL: server.URL + "/health", Method: "GET X"},
+			{Name: "bad url", URL: "{ip}:9090/health"},
+		},
+	}
+
+	statuses := make(map[string][]string)
+	for _, result := range NewHTTPChecker(cfg).Check() {
+		statuses[result.Item] = append(statuses[result.Item], result.Status)
+	}
+
+	tests := []struct {
+		name   string
+		status string
+		count  int
+	}{
+		{"health", "Passed", 2},
+		{"post", "Passed", 2},
+		{"regex", "Failed", 2},
+		{"json", "Failed", 2},
+		{"status", "Failed", 2},
+		// 配置错误只报告一次，不按节点重复
+		{"bad regex", "Failed", 1},
+		{"bad method", "Failed", 1},
+		{"bad url", "Failed", 1},
+	}
+	for _, tt := range tests {
+		got := statuses[tt.name]
+		if len(got) != tt.count {
+			t.Errorf("%s: expected %d results, got %v", tt.name, tt.count, got)
+			continue
+		}
+		for _, status := range got {
+			if status != tt.status {
+				t.Errorf("%s: expected status %s, got %s", tt.name, tt.status, status)
+			}
+		}
+	}
+}
+
+func TestTCPChecker(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	open := listener.Addr().(*net.TCPAddr).Port
+
+	// 关闭后的端口用于测试不可达
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closed := closedListener.Addr().(*net.TCPAddr).Port
+	closedListener.Close()
+	defer listener.Close()
+
+	cfg := &config.Config{
+		IPs: []config.IPConfig{{IP: "127.0.0.1", Role: "fp"}, {IP: "10.0.0.1", Role: "ops"}},
+		TCPChecks: []config.TCPCheckConfig{
+			{Name: "open", Roles: []string{"fp"}, Port: open},
+			{Name: "closed", Roles: []string{"fp"}, Port: closed},
+			{Name: "host", Roles: []string{"ops"}, Host: "127.0.0.1", Port: open},
+		},
+	}
+
+	results := NewTCPChecker(cfg).Check()
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	want := map[string]string{"open": "Passed", "closed": "Failed", "host": "Passed"}
+	for _, result := range results {
+		if result.Status != want[result.Item] {
+			t.Errorf("%s on %s: expected %s, got %s (%s)", result.Item, result.IP, want[result.Item], result.Status, result.Message)
+		}
+	}
+	// host 覆盖节点 IP，结果仍归属原节点
+	if results[2].IP != "10.0.0.1" {
+		t.Errorf("Unexpected host result: %+v", results[2])
+	}
+}
@@ -0,0 +1,207 @@
+package checker
+
+import (
+	"fmt"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/ssh"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 未配置时的默认扫描范围
+const (
+	defaultLogSince    = time.Hour
+	defaultLogLines    = 5000
+	defaultLogMaxLines = 5
+)
+
+type LogsChecker struct {
+	config *config.Config
+}
+
+func NewLogsChecker(cfg *config.Config) *LogsChecker {
+	return &LogsChecker{
+		config: cfg,
+	}
+}
+
+func (l *LogsChecker) Name() string {
+	return "logs"
+}
+
+func (l *LogsChecker) Check() []CheckResult {
+	return l.CheckComponent("")
+}
+
+// CheckComponent 只扫描指定组件的日志，component 为空时扫描全部
+func (l *LogsChecker) CheckComponent(component string) []CheckResult {
+	var results []CheckResult
+
+	for _, ip := range l.config.IPs {
+		var sources []config.LogSourceConfig
+		for _, source := range l.config.Logs.Sources {
+			if source.AppliesTo(ip.Role) && (component == "" || source.Component == component) {
+				sources = append(sources, source)
+			}
+		}
+		if len(sources) == 0 {
+			continue
+		}
+		results = append(results, l.checkHost(ip, sources)...)
+	}
+
+	return results
+}
+
+func (l *LogsChecker) checkHost(ip config.IPConfig, sources []config.LogSourceConfig) []CheckResult {
+	client := ssh.NewClient(ip.IP, ip.User, ip.Password, ip.Port)
+	if err := client.Connect(); err != nil {
+		return []CheckResult{l.createFailedResult("Logs", ip, "Failed to establish SSH connection", err)}
+	}
+	defer client.Close()
+
+	var results []CheckResult
+	for _, source := range sources {
+		results = append(results, l.scanSource(client, ip, source))
+	}
+	return results
+}
+
+func (l *LogsChecker) scanSource(client *ssh.Client, ip config.IPConfig, source config.LogSourceConfig) CheckResult {
+	item := source.Component + " Logs"
+
+	patterns := source.Patterns
+	if len(patterns) == 0 {
+		patterns = l.config.Logs.Patterns
+	}
+	regexps, err := compilePatterns(patterns)
+	if err != nil {
+		return l.createFailedResult(item, ip, "Invalid log pattern", err)
+	}
+
+	command, scope := l.logCommand(source)
+	if command == "" {
+		return l.createFailedResult(item, ip, "Log source needs either file or unit", nil)
+	}
+	log.Info("Scanning %s on %s", scope, ip.IP)
+
+	output, err := client.RunCommand(command)
+	if err != nil {
+		return l.createFailedResult(item, ip, fmt.Sprintf("Failed to read %s", scope), err)
+	}
+
+	counts, total, recent := scanLogLines(strings.Split(output, "\n"), regexps, l.maxLines())
+
+	result := l.createBaseResult(item, ip)
+	var summary []string
+	for i, pattern := range patterns {
+		summary = append(summary, fmt.Sprintf("%q: %d", pattern, counts[i]))
+	}
+
+	if total == 0 {
+		result.Status = "Passed"
+		result.Message = fmt.Sprintf("No errors in %s", scope)
+		log.Info("No error patterns found in %s on %s", scope, ip.IP)
+		return result
+	}
+
+	result.Status = "Warning"
+	result.Message = fmt.Sprintf("%d matching lines in %s (%s)", total, scope, strings.Join(summary, ", "))
+	result.Details = recent
+	log.Warn("Found %d error lines in %s on %s", total, scope, ip.IP)
+
+	return result
+}
+
+// logCommand 返回读取日志的命令及其描述
+func (l *LogsChecker) logCommand(source config.LogSourceConfig) (string, string) {
+	if source.Unit != "" {
+		since := l.config.Logs.Since
+		if since <= 0 {
+			since = defaultLogSince
+		}
+		return fmt.Sprintf("journalctl -u '%s' --since -%ds --no-pager -o short-iso", source.Unit, int(since.Seconds())),
+			fmt.Sprintf("journal of %s (last %s)", source.Unit, since)
+	}
+
+	if source.File != "" {
+		lines := l.config.Logs.Lines
+		if lines <= 0 {
+			lines = defaultLogLines
+		}
+		return fmt.Sprintf("tail -n %d '%s'", lines, source.File),
+			fmt.Sprintf("%s (last %d lines)", source.File, lines)
+	}
+
+	return "", ""
+}
+
+func (l *LogsChecker) maxLines() int {
+	if l.config.Logs.MaxLines > 0 {
+		return l.config.Logs.MaxLines
+	}
+	return defaultLogMaxLines
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no patterns configured")
+	}
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %v", pattern, err)
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
+
+// scanLogLines 统计每个模式的匹配数和匹配任一模式的行数，一行匹配多个模式时只计一行，
+// 并返回最近的 maxLines 条匹配行
+func scanLogLines(lines []string, regexps []*regexp.Regexp, maxLines int) ([]int, int, []string) {
+	counts := make([]int, len(regexps))
+	total := 0
+	var recent []string
+
+	for _, line := range lines {
+		matched := false
+		for i, re := range regexps {
+			if re.MatchString(line) {
+				counts[i]++
+				matched = true
+			}
+		}
+		if !matched {
+			continue
+		}
+		total++
+		recent = append(recent, strings.TrimSpace(line))
+		if len(recent) > maxLines {
+			recent = recent[1:]
+		}
+	}
+
+	return counts, total, recent
+}
+
+func (l *LogsChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
+	return CheckResult{
+		Component: l.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+}
+
+func (l *LogsChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := l.createBaseResult(item, ip)
+	result.Status = "Failed"
+	result.Message = message
+	result.Error = err
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
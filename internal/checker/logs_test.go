@@ -0,0 +1,62 @@
+package checker
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"ops_cli/internal/config"
+)
+
+func TestScanLogLines(t *testing.T) {
+	output := `2024-12-17T08:00:01+0800 host prometheus[100]: level=info msg="Starting"
+2024-12-17T08:00:02+0800 host prometheus[100]: level=error msg="scrape failed" err="connection refused"
+2024-12-17T08:00:03+0800 host prometheus[100]: level=warn msg="slow"
+2024-12-17T08:00:04+0800 host prometheus[100]: level=error msg="panic: runtime error"
+2024-12-17T08:00:05+0800 host prometheus[100]: level=error msg="write failed"
+`
+	regexps := []*regexp.Regexp{
+		regexp.MustCompile(`level=error`),
+		regexp.MustCompile(`panic`),
+		regexp.MustCompile(`refused`),
+	}
+
+	counts, total, recent := scanLogLines(strings.Split(output, "\n"), regexps, 2)
+	if want := []int{3, 1, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+	// 同时匹配多个模式的行只计一次
+	if total != 3 {
+		t.Errorf("total = %d, want 3", total)
+	}
+	if len(recent) != 2 || !strings.Contains(recent[0], "panic") || !strings.Contains(recent[1], "write failed") {
+		t.Errorf("recent = %q", recent)
+	}
+
+	counts, total, recent = scanLogLines([]string{""}, regexps, 2)
+	if total != 0 || len(recent) != 0 || !reflect.DeepEqual(counts, []int{0, 0, 0}) {
+		t.Errorf("empty output: %v, %d, %q", counts, total, recent)
+	}
+}
+
+func TestLogCommand(t *testing.T) {
+	l := NewLogsChecker(&config.Config{})
+	tests := []struct {
+		source config.LogSourceConfig
+		want   string
+	}{
+		{config.LogSourceConfig{Unit: "prometheus.service"}, "journalctl -u 'prometheus.service' --since -"},
+		{config.LogSourceConfig{File: "/var/log/my app.log"}, "tail -n "},
+		{config.LogSourceConfig{}, ""},
+	}
+	for _, tt := range tests {
+		command, _ := l.logCommand(tt.source)
+		if !strings.HasPrefix(command, tt.want) {
+			t.Errorf("logCommand(%+v) = %q, want prefix %q", tt.source, command, tt.want)
+		}
+		if tt.source.File != "" && !strings.HasSuffix(command, " '/var/log/my app.log'") {
+			t.Errorf("logCommand(%+v) = %q, file not quoted", tt.source, command)
+		}
+	}
+}
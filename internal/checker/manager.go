@@ -23,6 +23,16 @@ func (m *Manager) registerCheckers() {
 	m.checkers["ssh"] = NewSSHChecker(m.config.IPs)
 	m.checkers["prometheus"] = NewPrometheusChecker(m.config)
 	m.checkers["system"] = NewSystemChecker(m.config)
+	m.checkers["pushgateway"] = NewPushgatewayChecker(m.config)
+	m.checkers["alertmanager"] = NewAlertmanagerChecker(m.config)
+	m.checkers["exporter"] = NewExporterChecker(m.config)
+	m.checkers["http"] = NewHTTPChecker(m.config)
+	m.checkers["tcp"] = NewTCPChecker(m.config)
+	m.checkers["services"] = NewServicesChecker(m.config)
+	m.checkers["certificate"] = NewCertificateChecker(m.config)
+	m.checkers["logs"] = NewLogsChecker(m.config)
+	m.checkers["drift"] = NewDriftChecker(m.config)
+	m.checkers["network"] = NewNetworkChecker(m.config)
 }
 
 func (m *Manager) Check(component string) []CheckResult {
@@ -41,6 +51,12 @@ func (m *Manager) Check(component string) []CheckResult {
 	}}
 }
 
+// Checker 返回已注册的检查器，用于读取检查后的附加数据
+func (m *Manager) Checker(name string) (Checker, bool) {
+	checker, ok := m.checkers[name]
+	return checker, ok
+}
+
 func (m *Manager) checkAll() []CheckResult {
 	var results []CheckResult
 	for _, checker := range m.checkers {
@@ -0,0 +1,261 @@
+package checker
+
+import (
+	"fmt"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/ssh"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 未配置 network.timeout 时的默认连接超时
+const defaultNetworkTimeout = 3 * time.Second
+
+type NetworkChecker struct {
+	config *config.Config
+	matrix *MeshMatrix
+}
+
+// MeshMatrix 记录每个源节点到目标端口的探测结果
+type MeshMatrix struct {
+	Sources []string
+	Targets []string
+	Cells   map[string]map[string]MeshCell
+}
+
+// MeshCell 表示一次远程 TCP 探测的结果
+type MeshCell struct {
+	OK      bool
+	Latency time.Duration
+	// Rough 为 true 时远端 bash 不支持 EPOCHREALTIME，Latency 为包含进程启动的整个探测耗时
+	Rough bool
+}
+
+// FormatLatency 返回延迟，粗略值以 ~ 开头
+func (c MeshCell) FormatLatency() string {
+	if c.Rough {
+		return "~" + c.Latency.String()
+	}
+	return c.Latency.String()
+}
+
+// meshTarget 表示一个需要探测的目标端口
+type meshTarget struct {
+	Host      config.IPConfig
+	Port      int
+	Component string
+}
+
+func (t meshTarget) label() string {
+	return fmt.Sprintf("%s:%d", t.Host.IP, t.Port)
+}
+
+func NewNetworkChecker(cfg *config.Config) *NetworkChecker {
+	return &NetworkChecker{
+		config: cfg,
+	}
+}
+
+func (n *NetworkChecker) Name() string {
+	return "network"
+}
+
+// Matrix 返回最近一次 Check 得到的连通性矩阵
+func (n *NetworkChecker) Matrix() *MeshMatrix {
+	return n.matrix
+}
+
+func (n *NetworkChecker) Check() []CheckResult {
+	var results []CheckResult
+
+	targets := n.targets()
+	n.matrix = &MeshMatrix{Cells: make(map[string]map[string]MeshCell)}
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		if !seen[target.label()] {
+			seen[target.label()] = true
+			n.matrix.Targets = append(n.matrix.Targets, target.label())
+		}
+	}
+
+	for _, ip := range n.config.IPs {
+		results = append(results, n.checkSource(ip, targets)...)
+	}
+
+	return results
+}
+
+// targets 返回所有节点上按角色配置的组件端口，同一地址只探测一次
+func (n *NetworkChecker) targets() []meshTarget {
+	var targets []meshTarget
+	seen := make(map[string]bool)
+
+	for _, host := range n.config.IPs {
+		ports := config.ComponentPorts(host.Role)
+		components := make([]string, 0, len(ports))
+		for component := range ports {
+			components = append(components, component)
+		}
+		sort.Strings(components)
+
+		for _, component := range components {
+			target := meshTarget{Host: host, Port: ports[component], Component: component}
+			if seen[target.label()] {
+				continue
+			}
+			seen[target.label()] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+func (n *NetworkChecker) checkSource(ip config.IPConfig, targets []meshTarget) []CheckResult {
+	source := fmt.Sprintf("%s (%s)", ip.IP, ip.Role)
+	n.matrix.Sources = append(n.matrix.Sources, source)
+	n.matrix.Cells[source] = make(map[string]MeshCell)
+
+	log.Info("Checking network connectivity from %s", ip.IP)
+
+	client := ssh.NewClient(ip.IP, ip.User, ip.Password, ip.Port)
+	if err := client.Connect(); err != nil {
+		return []CheckResult{n.createFailedResult("Network Mesh", ip, "Failed to establish SSH connection", err)}
+	}
+	defer client.Close()
+
+	var remote []meshTarget
+	for _, target := range targets {
+		if target.Host.IP != ip.IP {
+			remote = append(remote, target)
+		}
+	}
+
+	var results []CheckResult
+	if len(remote) > 0 {
+		results = append(results, n.probeTargets(client, ip, source, remote)...)
+	}
+	for _, name := range n.config.Network.DNSNames {
+		results = append(results, n.checkDNS(client, ip, name))
+	}
+	return results
+}
+
+func (n *NetworkChecker) probeTargets(client *ssh.Client, ip config.IPConfig, source string, targets []meshTarget) []CheckResult {
+	timeout := n.config.Network.Timeout
+	if timeout <= 0 {
+		timeout = defaultNetworkTimeout
+	}
+
+	output, err := client.RunCommand(meshScript(targets, timeout))
+	if err != nil {
+		return []CheckResult{n.createFailedResult("Network Mesh", ip, "Failed to run connectivity probe", err)}
+	}
+	probes := parseMeshOutput(output)
+
+	var results []CheckResult
+	for _, target := range targets {
+		item := fmt.Sprintf("TCP %s (%s)", target.label(), target.Component)
+		cell, ok := probes[target.label()]
+		n.matrix.Cells[source][target.label()] = cell
+
+		if !ok || !cell.OK {
+			results = append(results, n.createFailedResult(item, ip, fmt.Sprintf("%s unreachable within %s", target.label(), timeout), nil))
+			continue
+		}
+
+		result := n.createBaseResult(item, ip)
+		result.Status = "Passed"
+		if cell.Rough {
+			result.Message = fmt.Sprintf("Reachable, probe took %s including process start", cell.FormatLatency())
+		} else {
+			result.Message = fmt.Sprintf("Reachable, connected in %s", cell.Latency)
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (n *NetworkChecker) checkDNS(client *ssh.Client, ip config.IPConfig, name string) CheckResult {
+	item := "DNS " + name
+	log.Info("Resolving %s on %s", name, ip.IP)
+
+	output, err := client.RunCommand(fmt.Sprintf("getent hosts '%s'", name))
+	if err != nil || strings.TrimSpace(output) == "" {
+		return n.createFailedResult(item, ip, fmt.Sprintf("Failed to resolve %s", name), err)
+	}
+
+	var addrs []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			addrs = append(addrs, fields[0])
+		}
+	}
+
+	result := n.createBaseResult(item, ip)
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("Resolved to %s", strings.Join(addrs, ", "))
+	return result
+}
+
+// meshScript 生成在远端逐个探测目标端口的 shell 脚本，依赖 bash 的 /dev/tcp。
+// bash 5 起用 EPOCHREALTIME 只计算建连耗时，更早的版本退回到包含进程启动的整个探测耗时
+func meshScript(targets []meshTarget, timeout time.Duration) string {
+	seconds := int(timeout.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	var pairs []string
+	for _, target := range targets {
+		pairs = append(pairs, fmt.Sprintf("'%s %d'", target.Host.IP, target.Port))
+	}
+
+	connect := `a=$EPOCHREALTIME; exec 3<>/dev/tcp/$0/$1 || exit 1; b=$EPOCHREALTIME; ` +
+		`if [ -n "$a" ]; then echo $(( 10#${b//[.,]/} - 10#${a//[.,]/} )); fi`
+	return fmt.Sprintf(`for t in %s; do set -- $t; s=$(date +%%s%%N); `+
+		`if r=$(timeout %d bash -c '%s' "$1" "$2" 2>/dev/null); then `+
+		`if [ -n "$r" ]; then echo "$1:$2 ok $r"; else echo "$1:$2 ok $(( ($(date +%%s%%N) - s) / 1000 )) rough"; fi; `+
+		`else echo "$1:$2 fail"; fi; done`,
+		strings.Join(pairs, " "), seconds, connect)
+}
+
+// parseMeshOutput 解析探测脚本的输出，延迟单位为微秒
+func parseMeshOutput(output string) map[string]MeshCell {
+	probes := make(map[string]MeshCell)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		cell := MeshCell{OK: fields[1] == "ok", Rough: len(fields) >= 4 && fields[3] == "rough"}
+		if cell.OK && len(fields) >= 3 {
+			if us, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+				cell.Latency = (time.Duration(us) * time.Microsecond).Round(10 * time.Microsecond)
+			}
+		}
+		probes[fields[0]] = cell
+	}
+	return probes
+}
+
+func (n *NetworkChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
+	return CheckResult{
+		Component: n.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+}
+
+func (n *NetworkChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := n.createBaseResult(item, ip)
+	result.Status = "Failed"
+	result.Message = message
+	result.Error = err
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
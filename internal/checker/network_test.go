@@ -0,0 +1,72 @@
+package checker
+
+import (
+	"net"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"ops_cli/internal/config"
+)
+
+func TestParseMeshOutput(t *testing.T) {
+	output := `10.0.0.1:9090 ok 1234
+10.0.0.2:9090 ok 56789 rough
+10.0.0.3:9090 fail
+10.0.0.4:9090 ok
+
+`
+	want := map[string]MeshCell{
+		"10.0.0.1:9090": {OK: true, Latency: 1230 * time.Microsecond},
+		"10.0.0.2:9090": {OK: true, Latency: 56790 * time.Microsecond, Rough: true},
+		"10.0.0.3:9090": {OK: false},
+		"10.0.0.4:9090": {OK: true},
+	}
+	if got := parseMeshOutput(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMeshOutput = %+v, want %+v", got, want)
+	}
+
+	if got := want["10.0.0.2:9090"].FormatLatency(); got != "~56.79ms" {
+		t.Errorf("FormatLatency = %q", got)
+	}
+}
+
+// 在本地执行探测脚本，验证输出能被 parseMeshOutput 解析
+func TestMeshScript(t *testing.T) {
+	for _, name := range []string{"bash", "timeout"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s not available", name)
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	open := listener.Addr().(*net.TCPAddr).Port
+
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closed := closedListener.Addr().(*net.TCPAddr).Port
+	closedListener.Close()
+
+	host := config.IPConfig{IP: "127.0.0.1"}
+	targets := []meshTarget{{Host: host, Port: open}, {Host: host, Port: closed}}
+	output, err := exec.Command("sh", "-c", meshScript(targets, time.Second)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("meshScript failed: %v\n%s", err, output)
+	}
+
+	cells := parseMeshOutput(string(output))
+	if cell := cells["127.0.0.1:"+strconv.Itoa(open)]; !cell.OK {
+		t.Errorf("open port: %+v\n%s", cell, output)
+	}
+	if cell, ok := cells["127.0.0.1:"+strconv.Itoa(closed)]; !ok || cell.OK {
+		t.Errorf("closed port: %+v\n%s", cell, output)
+	}
+}
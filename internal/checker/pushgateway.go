@@ -0,0 +1,265 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 未配置 max_age 时推送组的默认过期时长
+const defaultPushgatewayMaxAge = 10 * time.Minute
+
+type PushgatewayChecker struct {
+	config *config.Config
+	client *http.Client
+}
+
+// pushGroup 对应 /api/v1/metrics 返回的单个推送组
+type pushGroup struct {
+	Labels          map[string]string
+	PushTime        float64
+	PushFailureTime float64
+}
+
+type pushgatewayMetricFamily struct {
+	Metrics []struct {
+		Value string `json:"value"`
+	} `json:"metrics"`
+}
+
+func NewPushgatewayChecker(cfg *config.Config) *PushgatewayChecker {
+	return &PushgatewayChecker{
+		config: cfg,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (p *PushgatewayChecker) Name() string {
+	return "pushgateway"
+}
+
+func (p *PushgatewayChecker) Check() []CheckResult {
+	var results []CheckResult
+
+	for _, ip := range p.config.IPs {
+		health := p.checkHealth(ip)
+		results = append(results, health)
+		if health.Status != "Passed" {
+			continue
+		}
+		results = append(results, p.checkGroups(ip)...)
+	}
+
+	return results
+}
+
+func (p *PushgatewayChecker) maxAge() time.Duration {
+	if p.config.Pushgateway.MaxAge > 0 {
+		return p.config.Pushgateway.MaxAge
+	}
+	return defaultPushgatewayMaxAge
+}
+
+func (p *PushgatewayChecker) checkHealth(ip config.IPConfig) CheckResult {
+	log.Info("Checking Pushgateway health for %s", ip.IP)
+
+	baseUrl, err := config.GetUrl(ip.IP, ip.Role, config.ComponentPushgateway, config.PathHealth)
+	if err != nil {
+		return p.createFailedResult("API Health", ip, "Failed to get base url", err)
+	}
+	log.Debug("Making HTTP request to %s with timeout %v", baseUrl, p.client.Timeout)
+
+	resp, err := p.client.Get(baseUrl)
+	if err != nil {
+		return p.createFailedResult("API Health", ip, "API health check failed", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return p.createFailedResult("API Health", ip, fmt.Sprintf("API returned status code %d", resp.StatusCode), nil)
+	}
+
+	result := p.createBaseResult("API Health", ip)
+	result.Status = "Passed"
+	result.Message = "API is healthy"
+	log.Info("Pushgateway health check passed for %s", ip.IP)
+
+	return result
+}
+
+func (p *PushgatewayChecker) checkGroups(ip config.IPConfig) []CheckResult {
+	log.Info("Checking Pushgateway push groups for %s", ip.IP)
+
+	groups, err := p.fetchGroups(ip)
+	if err != nil {
+		return []CheckResult{p.createFailedResult("Push Groups", ip, "Failed to get push groups", err)}
+	}
+
+	if len(groups) == 0 {
+		result := p.createBaseResult("Push Groups", ip)
+		result.Status = "Passed"
+		result.Message = "No push groups found"
+		return []CheckResult{result}
+	}
+
+	now := time.Now()
+	maxAge := p.maxAge()
+
+	var results []CheckResult
+	for _, group := range groups {
+		results = append(results, p.evaluateGroup(ip, group, now, maxAge))
+	}
+	return results
+}
+
+func (p *PushgatewayChecker) evaluateGroup(ip config.IPConfig, group pushGroup, now time.Time, maxAge time.Duration) CheckResult {
+	item := fmt.Sprintf("Push Group {%s}", formatGroupLabels(group.Labels))
+	result := p.createBaseResult(item, ip)
+
+	pushTime := unixSecondsToTime(group.PushTime)
+	age := now.Sub(pushTime).Truncate(time.Second)
+
+	// 最近一次推送失败的时间晚于成功时间，说明最后一次推送失败
+	if group.PushFailureTime > 0 && group.PushFailureTime >= group.PushTime {
+		failureTime := unixSecondsToTime(group.PushFailureTime)
+		result.Status = "Failed"
+		result.Message = fmt.Sprintf("Last push failed at %s", failureTime.Format("2006-01-02 15:04:05"))
+		log.Error("Push group %s on %s: %s", item, ip.IP, result.Message)
+		return result
+	}
+
+	if group.PushTime == 0 {
+		result.Status = "Warning"
+		result.Message = "Group has never been pushed successfully"
+		log.Warn("Push group %s on %s: %s", item, ip.IP, result.Message)
+		return result
+	}
+
+	if age > maxAge {
+		result.Status = "Warning"
+		result.Message = fmt.Sprintf("Last push at %s (%s ago, exceeds %s)", pushTime.Format("2006-01-02 15:04:05"), age, maxAge)
+		log.Warn("Push group %s on %s is stale: %s", item, ip.IP, result.Message)
+		return result
+	}
+
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("Last push at %s (%s ago)", pushTime.Format("2006-01-02 15:04:05"), age)
+	return result
+}
+
+func (p *PushgatewayChecker) fetchGroups(ip config.IPConfig) ([]pushGroup, error) {
+	baseUrl, err := config.GetUrl(ip.IP, ip.Role, config.ComponentPushgateway, config.PathMetrics)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Fetching push groups from %s", baseUrl)
+
+	resp, err := p.client.Get(baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	return parsePushGroups(body)
+}
+
+func parsePushGroups(body []byte) ([]pushGroup, error) {
+	var jsonResponse struct {
+		Status string                       `json:"status"`
+		Data   []map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &jsonResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %v", err)
+	}
+	if jsonResponse.Status != "success" {
+		return nil, fmt.Errorf("API returned status %q", jsonResponse.Status)
+	}
+
+	var groups []pushGroup
+	for _, data := range jsonResponse.Data {
+		var group pushGroup
+		if raw, ok := data["labels"]; ok {
+			if err := json.Unmarshal(raw, &group.Labels); err != nil {
+				return nil, fmt.Errorf("failed to parse group labels: %v", err)
+			}
+		}
+		group.PushTime = firstFamilyValue(data["push_time_seconds"])
+		group.PushFailureTime = firstFamilyValue(data["push_failure_time_seconds"])
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return formatGroupLabels(groups[i].Labels) < formatGroupLabels(groups[j].Labels)
+	})
+
+	return groups, nil
+}
+
+func firstFamilyValue(raw json.RawMessage) float64 {
+	if len(raw) == 0 {
+		return 0
+	}
+	var family pushgatewayMetricFamily
+	if err := json.Unmarshal(raw, &family); err != nil || len(family.Metrics) == 0 {
+		return 0
+	}
+	value, err := strconv.ParseFloat(family.Metrics[0].Value, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+func formatGroupLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func unixSecondsToTime(seconds float64) time.Time {
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second)))
+}
+
+func (p *PushgatewayChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
+	return CheckResult{
+		Component: p.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+}
+
+func (p *PushgatewayChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := p.createBaseResult(item, ip)
+	result.Status = "Failed"
+	result.Message = message
+	result.Error = err
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
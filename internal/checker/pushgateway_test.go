@@ -0,0 +1,66 @@
+package checker
+
+import (
+	"testing"
+	"time"
+
+	"ops_cli/internal/config"
+)
+
+const pushgatewayMetricsBody = `{
+  "status": "success",
+  "data": [
+    {
+      "labels": {"job": "backup", "instance": "db1"},
+      "last_push_successful": true,
+      "push_time_seconds": {"metrics": [{"labels": {}, "value": "1700000000"}]},
+      "push_failure_time_seconds": {"metrics": [{"labels": {}, "value": "0"}]}
+    },
+    {
+      "labels": {"job": "batch"},
+      "last_push_successful": false,
+      "push_time_seconds": {"metrics": [{"labels": {}, "value": "1700000000"}]},
+      "push_failure_time_seconds": {"metrics": [{"labels": {}, "value": "1700000100"}]}
+    }
+  ]
+}`
+
+func TestParsePushGroups(t *testing.T) {
+	groups, err := parsePushGroups([]byte(pushgatewayMetricsBody))
+	if err != nil {
+		t.Fatalf("parsePushGroups returned error: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+	if groups[0].Labels["job"] != "backup" || groups[0].PushTime != 1700000000 {
+		t.Errorf("Unexpected first group: %+v", groups[0])
+	}
+	if groups[1].PushFailureTime != 1700000100 {
+		t.Errorf("Expected failure time 1700000100, got %v", groups[1].PushFailureTime)
+	}
+}
+
+func TestEvaluateGroup(t *testing.T) {
+	p := NewPushgatewayChecker(&config.Config{})
+	ip := config.IPConfig{IP: "127.0.0.1", Role: "ops"}
+	now := time.Unix(1700000300, 0)
+
+	tests := []struct {
+		name   string
+		group  pushGroup
+		status string
+	}{
+		{"fresh", pushGroup{PushTime: 1700000200}, "Passed"},
+		{"stale", pushGroup{PushTime: 1699990000}, "Warning"},
+		{"failed", pushGroup{PushTime: 1700000200, PushFailureTime: 1700000250}, "Failed"},
+		{"never pushed", pushGroup{}, "Warning"},
+	}
+
+	for _, tt := range tests {
+		result := p.evaluateGroup(ip, tt.group, now, 5*time.Minute)
+		if result.Status != tt.status {
+			t.Errorf("%s: expected status %s, got %s (%s)", tt.name, tt.status, result.Status, result.Message)
+		}
+	}
+}
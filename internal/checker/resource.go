@@ -0,0 +1,339 @@
+package checker
+
+import (
+	"fmt"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/ssh"
+	"strconv"
+	"strings"
+)
+
+// 未配置阈值时使用的内置默认值
+var defaultResourceThresholds = config.ResourceThresholds{
+	Disk:   config.Threshold{Warning: 80, Critical: 90},
+	Inode:  config.Threshold{Warning: 80, Critical: 90},
+	Memory: config.Threshold{Warning: 85, Critical: 95},
+	Swap:   config.Threshold{Warning: 50, Critical: 80},
+	Load:   config.Threshold{Warning: 1, Critical: 2},
+	FD:     config.Threshold{Warning: 70, Critical: 90},
+}
+
+// diskUsage 表示 df 输出中的一个挂载点
+type diskUsage struct {
+	Mount        string
+	UsedPercent  float64
+	InodePercent float64 // 文件系统不支持 inode 统计时为 -1
+}
+
+// resourceThresholds 依次用 default 和角色配置覆盖内置默认值
+func (s *SystemChecker) resourceThresholds(role string) config.ResourceThresholds {
+	result := defaultResourceThresholds
+	// viper 会把 map 的键转为小写
+	for _, key := range []string{"default", strings.ToLower(role)} {
+		override, ok := s.config.System.Thresholds[key]
+		if !ok {
+			continue
+		}
+		mergeThreshold(&result.Disk, override.Disk)
+		mergeThreshold(&result.Inode, override.Inode)
+		mergeThreshold(&result.Memory, override.Memory)
+		mergeThreshold(&result.Swap, override.Swap)
+		mergeThreshold(&result.Load, override.Load)
+		mergeThreshold(&result.FD, override.FD)
+		// 角色的挂载点配置排在前面，优先匹配
+		result.Mounts = append(append([]config.MountThreshold{}, override.Mounts...), result.Mounts...)
+	}
+	return result
+}
+
+// mergeThreshold 用 src 中配置的级别覆盖 dst，负数会覆盖并关闭该级别
+func mergeThreshold(dst *config.Threshold, src config.Threshold) {
+	if src.Warning != 0 {
+		dst.Warning = src.Warning
+	}
+	if src.Critical != 0 {
+		dst.Critical = src.Critical
+	}
+}
+
+// formatLimit 返回百分比阈值，关闭的级别为 off
+func formatLimit(v float64) string {
+	if v <= 0 {
+		return "off"
+	}
+	return fmt.Sprintf("%.0f%%", v)
+}
+
+// diskThreshold 返回挂载点的阈值，未单独配置时使用 Disk
+func diskThreshold(thresholds config.ResourceThresholds, mount string) config.Threshold {
+	for _, m := range thresholds.Mounts {
+		if m.Path == mount {
+			t := thresholds.Disk
+			mergeThreshold(&t, m.Threshold)
+			return t
+		}
+	}
+	return thresholds.Disk
+}
+
+// evaluateThreshold 根据阈值返回检查状态
+func evaluateThreshold(value float64, t config.Threshold) string {
+	switch {
+	case t.Critical > 0 && value >= t.Critical:
+		return "Failed"
+	case t.Warning > 0 && value >= t.Warning:
+		return "Warning"
+	default:
+		return "Passed"
+	}
+}
+
+// worseStatus 返回两个状态中更严重的一个
+func worseStatus(a, b string) string {
+	rank := map[string]int{"Passed": 0, "Warning": 1, "Failed": 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+func (s *SystemChecker) checkResources(client *ssh.Client, ip config.IPConfig) []CheckResult {
+	log.Info("Checking resource usage for %s", ip.IP)
+
+	thresholds := s.resourceThresholds(ip.Role)
+
+	var results []CheckResult
+	results = append(results, s.checkDisks(client, ip, thresholds)...)
+	results = append(results,
+		s.checkMemory(client, ip, thresholds),
+		s.checkLoad(client, ip, thresholds),
+		s.checkFileDescriptors(client, ip, thresholds),
+	)
+	return results
+}
+
+func (s *SystemChecker) checkDisks(client *ssh.Client, ip config.IPConfig, thresholds config.ResourceThresholds) []CheckResult {
+	const pseudoFS = "-x tmpfs -x devtmpfs -x squashfs -x overlay"
+
+	// 有挂载点无法访问（如失效的 NFS）时 df 退出码非 0，但其他挂载点的输出仍然有效
+	blocks, err := client.RunCommand("df -P -k " + pseudoFS + " || true")
+	if err != nil {
+		return []CheckResult{s.createFailedResult("Disk", ip, "Failed to get filesystem usage", err)}
+	}
+	inodes, err := client.RunCommand("df -P -i " + pseudoFS + " 2>/dev/null || true")
+	if err != nil {
+		log.Warn("Failed to get inode usage for %s: %v", ip.IP, err)
+		inodes = ""
+	}
+
+	disks := parseDiskUsage(blocks, inodes)
+	if len(disks) == 0 {
+		return []CheckResult{s.createFailedResult("Disk", ip, fmt.Sprintf("Failed to get filesystem usage: %s", strings.TrimSpace(blocks)), nil)}
+	}
+
+	var results []CheckResult
+	if failures := dfErrors(blocks); len(failures) > 0 {
+		result := s.createBaseResult("Disk", ip)
+		result.Status = "Warning"
+		result.Message = fmt.Sprintf("%d filesystems could not be read", len(failures))
+		result.Details = failures
+		s.logResource(ip, result)
+		results = append(results, result)
+	}
+	for _, disk := range disks {
+		item := "Disk " + disk.Mount
+		t := diskThreshold(thresholds, disk.Mount)
+
+		result := s.createBaseResult(item, ip)
+		result.Status = evaluateThreshold(disk.UsedPercent, t)
+		result.Message = fmt.Sprintf("Used %.0f%%", disk.UsedPercent)
+		if disk.InodePercent >= 0 {
+			result.Status = worseStatus(result.Status, evaluateThreshold(disk.InodePercent, thresholds.Inode))
+			result.Message += fmt.Sprintf(", inodes %.0f%%", disk.InodePercent)
+		}
+		result.Message += fmt.Sprintf(" (warning %s, critical %s)", formatLimit(t.Warning), formatLimit(t.Critical))
+		s.logResource(ip, result)
+		results = append(results, result)
+	}
+	return results
+}
+
+func (s *SystemChecker) checkMemory(client *ssh.Client, ip config.IPConfig, thresholds config.ResourceThresholds) CheckResult {
+	output, err := client.RunCommand("cat /proc/meminfo")
+	if err != nil {
+		return s.createFailedResult("Memory", ip, "Failed to read /proc/meminfo", err)
+	}
+
+	info := parseMeminfo(output)
+	memTotal, memAvailable := info["MemTotal"], info["MemAvailable"]
+	if memTotal == 0 {
+		return s.createFailedResult("Memory", ip, "MemTotal not found in /proc/meminfo", nil)
+	}
+	memPercent := (memTotal - memAvailable) / memTotal * 100
+
+	result := s.createBaseResult("Memory", ip)
+	result.Status = evaluateThreshold(memPercent, thresholds.Memory)
+	result.Message = fmt.Sprintf("Used %.1f%% of %.1f GiB", memPercent, memTotal/1024/1024)
+
+	if swapTotal := info["SwapTotal"]; swapTotal > 0 {
+		swapPercent := (swapTotal - info["SwapFree"]) / swapTotal * 100
+		result.Status = worseStatus(result.Status, evaluateThreshold(swapPercent, thresholds.Swap))
+		result.Message += fmt.Sprintf(", swap %.1f%%", swapPercent)
+	}
+
+	s.logResource(ip, result)
+	return result
+}
+
+func (s *SystemChecker) checkLoad(client *ssh.Client, ip config.IPConfig, thresholds config.ResourceThresholds) CheckResult {
+	// 优先使用缓存的 CPU 数，缓存缺失或过期时一次远程命令同时获取负载和 CPU 数
+	cpus := 0
+	if hostFacts, ok := s.facts.Lookup(ip); ok {
+		cpus = hostFacts.CPUs
+	}
+	command := "cat /proc/loadavg"
+	if cpus <= 0 {
+		command += " && { nproc 2>/dev/null || true; }"
+	}
+	output, err := client.RunCommand(command)
+	if err != nil {
+		return s.createFailedResult("Load", ip, "Failed to read load average", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	fields := strings.Fields(lines[0])
+	if len(fields) < 3 {
+		return s.createFailedResult("Load", ip, fmt.Sprintf("Unexpected /proc/loadavg %q", lines[0]), nil)
+	}
+	load1, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return s.createFailedResult("Load", ip, "Failed to parse load average", err)
+	}
+
+	if cpus <= 0 && len(lines) >= 2 {
+		cpus, _ = strconv.Atoi(strings.TrimSpace(lines[len(lines)-1]))
+	}
+	if cpus <= 0 {
+		return s.createFailedResult("Load", ip, "Failed to get CPU count", nil)
+	}
+
+	perCPU := load1 / float64(cpus)
+
+	result := s.createBaseResult("Load", ip)
+	result.Status = evaluateThreshold(perCPU, thresholds.Load)
+	result.Message = fmt.Sprintf("Load %s %s %s on %d CPUs (%.2f per CPU)", fields[0], fields[1], fields[2], cpus, perCPU)
+	s.logResource(ip, result)
+	return result
+}
+
+func (s *SystemChecker) checkFileDescriptors(client *ssh.Client, ip config.IPConfig, thresholds config.ResourceThresholds) CheckResult {
+	output, err := client.RunCommand("cat /proc/sys/fs/file-nr")
+	if err != nil {
+		return s.createFailedResult("File Descriptors", ip, "Failed to read /proc/sys/fs/file-nr", err)
+	}
+
+	// 格式为：已分配 未使用 最大值
+	fields := strings.Fields(output)
+	if len(fields) < 3 {
+		return s.createFailedResult("File Descriptors", ip, fmt.Sprintf("Unexpected file-nr %q", output), nil)
+	}
+	allocated, _ := strconv.ParseFloat(fields[0], 64)
+	fileMax, _ := strconv.ParseFloat(fields[2], 64)
+	if fileMax <= 0 {
+		return s.createFailedResult("File Descriptors", ip, fmt.Sprintf("Invalid file-max %q", fields[2]), nil)
+	}
+	percent := allocated / fileMax * 100
+
+	result := s.createBaseResult("File Descriptors", ip)
+	result.Status = evaluateThreshold(percent, thresholds.FD)
+	result.Message = fmt.Sprintf("%s of %s open (%.1f%%)", fields[0], fields[2], percent)
+	s.logResource(ip, result)
+	return result
+}
+
+func (s *SystemChecker) logResource(ip config.IPConfig, result CheckResult) {
+	switch result.Status {
+	case "Failed":
+		log.Error("%s check failed for %s: %s", result.Item, ip.IP, result.Message)
+	case "Warning":
+		log.Warn("%s check warning for %s: %s", result.Item, ip.IP, result.Message)
+	default:
+		log.Info("%s check passed for %s: %s", result.Item, ip.IP, result.Message)
+	}
+}
+
+// parseDiskUsage 合并 df -P -k 和 df -P -i 的输出
+func parseDiskUsage(blocks, inodes string) []diskUsage {
+	inodePercents := make(map[string]float64)
+	for _, fields := range dfRows(inodes) {
+		if percent, err := parsePercent(fields[4]); err == nil {
+			inodePercents[fields[5]] = percent
+		}
+	}
+
+	var disks []diskUsage
+	for _, fields := range dfRows(blocks) {
+		percent, err := parsePercent(fields[4])
+		if err != nil {
+			continue
+		}
+		disk := diskUsage{Mount: fields[5], UsedPercent: percent, InodePercent: -1}
+		if inode, ok := inodePercents[disk.Mount]; ok {
+			disk.InodePercent = inode
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
+// dfRows 返回 df -P 的数据行，挂载点中的空格会合并到最后一列
+func dfRows(output string) [][]string {
+	var rows [][]string
+	for i, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if i == 0 {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		fields = append(fields[:5], strings.Join(fields[5:], " "))
+		rows = append(rows, fields)
+	}
+	return rows
+}
+
+// dfErrors 返回 df 输出中的错误行，RunCommand 会合并标准错误
+func dfErrors(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "df:") {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+func parsePercent(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+}
+
+// parseMeminfo 解析 /proc/meminfo，单位为 kB
+func parseMeminfo(output string) map[string]float64 {
+	info := make(map[string]float64)
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		if v, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			info[strings.TrimSpace(key)] = v
+		}
+	}
+	return info
+}
@@ -0,0 +1,79 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDiskUsage(t *testing.T) {
+	blocks := `Filesystem     1024-blocks      Used Available Capacity Mounted on
+/dev/sda1         41152736  30864552   8175084      80% /
+tmpfs              8119012         0   8119012       0% /dev/shm
+/dev/sdb1        103081248  97926860         0     100% /data dir
+df: /mnt/nfs: Stale file handle
+
+/dev/sdc1          1048576     10240   1038336       1% /boot/efi
+`
+	inodes := `Filesystem      Inodes  IUsed   IFree IUse% Mounted on
+/dev/sda1      2621440 262144 2359296   10% /
+tmpfs          2029753      1 2029752    1% /dev/shm
+/dev/sdb1      6553600 6225920  327680   95% /data dir
+/dev/sdc1            0       0       0     - /boot/efi
+`
+	want := []diskUsage{
+		{Mount: "/", UsedPercent: 80, InodePercent: 10},
+		{Mount: "/dev/shm", UsedPercent: 0, InodePercent: 1},
+		{Mount: "/data dir", UsedPercent: 100, InodePercent: 95},
+		// vfat 等不支持 inode 统计的文件系统
+		{Mount: "/boot/efi", UsedPercent: 1, InodePercent: -1},
+	}
+	if got := parseDiskUsage(blocks, inodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDiskUsage = %+v, want %+v", got, want)
+	}
+
+	if got := parseDiskUsage(blocks, ""); len(got) != 4 || got[0].InodePercent != -1 {
+		t.Errorf("parseDiskUsage without inodes = %+v", got)
+	}
+	if got := parseDiskUsage("", ""); len(got) != 0 {
+		t.Errorf("parseDiskUsage of empty output = %+v", got)
+	}
+	if got := dfErrors(blocks); !reflect.DeepEqual(got, []string{"df: /mnt/nfs: Stale file handle"}) {
+		t.Errorf("dfErrors = %q", got)
+	}
+}
+
+func TestDfRows(t *testing.T) {
+	output := "Filesystem 1024-blocks Used Available Capacity Mounted on\n" +
+		"/dev/sda1 100 80 20 80% /\n" +
+		"\n" +
+		"short line\n" +
+		"/dev/sdb1 100 10 90 10% /mnt/my disk\n"
+	want := [][]string{
+		{"/dev/sda1", "100", "80", "20", "80%", "/"},
+		{"/dev/sdb1", "100", "10", "90", "10%", "/mnt/my disk"},
+	}
+	if got := dfRows(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("dfRows = %q, want %q", got, want)
+	}
+}
+
+func TestParseMeminfo(t *testing.T) {
+	output := `MemTotal:       16238024 kB
+MemFree:         1203400 kB
+MemAvailable:    9876540 kB
+SwapTotal:             0 kB
+HugePages_Total:       0
+Bogus line
+Empty:
+`
+	want := map[string]float64{
+		"MemTotal":        16238024,
+		"MemFree":         1203400,
+		"MemAvailable":    9876540,
+		"SwapTotal":       0,
+		"HugePages_Total": 0,
+	}
+	if got := parseMeminfo(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMeminfo = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,223 @@
+package checker
+
+import (
+	"fmt"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/ssh"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 单元在该时间内因重启重新进入 active 时给出告警，更早的重启只在消息中列出
+const serviceRestartWindow = 24 * time.Hour
+
+type ServicesChecker struct {
+	config *config.Config
+}
+
+// unitState 对应 systemctl show 中关心的属性
+type unitState struct {
+	LoadState   string
+	ActiveState string
+	SubState    string
+	Restarts    int
+	Since       string
+	// Age 为单元本次进入 active 到现在的时长，无法获取时为 -1
+	Age time.Duration
+}
+
+func NewServicesChecker(cfg *config.Config) *ServicesChecker {
+	return &ServicesChecker{
+		config: cfg,
+	}
+}
+
+func (s *ServicesChecker) Name() string {
+	return "services"
+}
+
+func (s *ServicesChecker) Check() []CheckResult {
+	var results []CheckResult
+
+	for _, ip := range s.config.IPs {
+		results = append(results, s.checkHost(ip)...)
+	}
+
+	return results
+}
+
+// units 返回角色需要运行的 systemd 单元
+func (s *ServicesChecker) units(role string) []string {
+	// viper 会把 map 的键转为小写
+	if units, ok := s.config.Services[strings.ToLower(role)]; ok {
+		return units
+	}
+	return s.config.Services["default"]
+}
+
+func (s *ServicesChecker) checkHost(ip config.IPConfig) []CheckResult {
+	log.Info("Checking services for %s", ip.IP)
+
+	client := ssh.NewClient(ip.IP, ip.User, ip.Password, ip.Port)
+	if err := client.Connect(); err != nil {
+		return []CheckResult{s.createFailedResult("Services", ip, "Failed to establish SSH connection", err)}
+	}
+	defer client.Close()
+
+	var results []CheckResult
+	for _, unit := range s.units(ip.Role) {
+		results = append(results, s.checkUnit(client, ip, unit))
+	}
+
+	return append(results, s.checkPorts(client, ip)...)
+}
+
+func (s *ServicesChecker) checkUnit(client *ssh.Client, ip config.IPConfig, unit string) CheckResult {
+	item := "Service " + unit
+	log.Info("Checking systemd unit %s on %s", unit, ip.IP)
+
+	// 用开机以来的单调时间计算进入 active 的时长，避免解析带时区缩写的时间戳
+	output, err := client.RunCommand(fmt.Sprintf("systemctl show '%s' --property=LoadState,ActiveState,SubState,NRestarts,ActiveEnterTimestamp,ActiveEnterTimestampMonotonic && echo Uptime=$(cut -d' ' -f1 /proc/uptime)", unit))
+	if err != nil {
+		return s.createFailedResult(item, ip, "Failed to query systemd", err)
+	}
+
+	state := parseUnitState(output)
+
+	if state.LoadState == "not-found" {
+		return s.createFailedResult(item, ip, "Unit not found", nil)
+	}
+	if state.ActiveState != "active" {
+		return s.createFailedResult(item, ip, fmt.Sprintf("Unit is %s (%s)", state.ActiveState, state.SubState), nil)
+	}
+
+	result := s.createBaseResult(item, ip)
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("%s (%s) since %s, %d restarts", state.ActiveState, state.SubState, state.Since, state.Restarts)
+	if state.Restarts > 0 && state.Age >= 0 && state.Age < serviceRestartWindow {
+		result.Status = "Warning"
+		result.Message += fmt.Sprintf(", last start %s ago", state.Age.Round(time.Second))
+		log.Warn("Unit %s on %s has restarted %d times, last start %s ago", unit, ip.IP, state.Restarts, state.Age.Round(time.Second))
+	}
+
+	return result
+}
+
+// checkPorts 确认端口配置中的组件端口在主机上处于监听状态
+func (s *ServicesChecker) checkPorts(client *ssh.Client, ip config.IPConfig) []CheckResult {
+	log.Info("Checking listening ports on %s", ip.IP)
+
+	// 旧版本 iproute2 不支持 -H，表头在解析时跳过
+	output, err := client.RunCommand("ss -ltn")
+	if err != nil {
+		return []CheckResult{s.createFailedResult("Listening Ports", ip, "Failed to list listening ports", err)}
+	}
+	listening := parseListeningPorts(output)
+
+	// 多个组件共用同一端口时（例如反向代理）合并为一条结果
+	byPort := make(map[int][]string)
+	for component, port := range config.ComponentPorts(ip.Role) {
+		byPort[port] = append(byPort[port], component)
+	}
+
+	ports := make([]int, 0, len(byPort))
+	for port := range byPort {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	var results []CheckResult
+	for _, port := range ports {
+		components := byPort[port]
+		sort.Strings(components)
+		item := fmt.Sprintf("Port %d", port)
+		names := strings.Join(components, ", ")
+
+		if !listening[port] {
+			results = append(results, s.createFailedResult(item, ip, fmt.Sprintf("Configured for %s but not listening", names), nil))
+			continue
+		}
+
+		result := s.createBaseResult(item, ip)
+		result.Status = "Passed"
+		result.Message = fmt.Sprintf("Listening for %s", names)
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// parseUnitState 解析 systemctl show 的 key=value 输出
+func parseUnitState(output string) unitState {
+	state := unitState{Age: -1}
+	var enteredUs int64
+	var uptime float64
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "LoadState":
+			state.LoadState = value
+		case "ActiveState":
+			state.ActiveState = value
+		case "SubState":
+			state.SubState = value
+		case "NRestarts":
+			state.Restarts, _ = strconv.Atoi(value)
+		case "ActiveEnterTimestamp":
+			state.Since = value
+		case "ActiveEnterTimestampMonotonic":
+			enteredUs, _ = strconv.ParseInt(value, 10, 64)
+		case "Uptime":
+			uptime, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+	if enteredUs > 0 && uptime > 0 {
+		state.Age = time.Duration(uptime*float64(time.Second)) - time.Duration(enteredUs)*time.Microsecond
+	}
+	return state
+}
+
+// parseListeningPorts 解析 ss -ltn 输出中的本地端口
+func parseListeningPorts(output string) map[int]bool {
+	ports := make(map[int]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] == "State" {
+			continue
+		}
+		// 本地地址形如 0.0.0.0:9090、[::]:9090 或 *:9090
+		local := fields[3]
+		idx := strings.LastIndex(local, ":")
+		if idx < 0 {
+			continue
+		}
+		if port, err := strconv.Atoi(local[idx+1:]); err == nil {
+			ports[port] = true
+		}
+	}
+	return ports
+}
+
+func (s *ServicesChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
+	return CheckResult{
+		Component: s.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+}
+
+func (s *ServicesChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := s.createBaseResult(item, ip)
+	result.Status = "Failed"
+	result.Message = message
+	result.Error = err
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
@@ -0,0 +1,61 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseUnitState(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   unitState
+	}{
+		{"running", `LoadState=loaded
+ActiveState=active
+SubState=running
+NRestarts=2
+ActiveEnterTimestamp=Tue 2024-12-17 08:00:00 CST
+ActiveEnterTimestampMonotonic=3600000000
+Uptime=7200.50
+`, unitState{LoadState: "loaded", ActiveState: "active", SubState: "running", Restarts: 2,
+			Since: "Tue 2024-12-17 08:00:00 CST", Age: 3600*time.Second + 500*time.Millisecond}},
+		// 未启动过的单元时间戳为空，Monotonic 为 0
+		{"inactive", `LoadState=loaded
+ActiveState=inactive
+SubState=dead
+NRestarts=0
+ActiveEnterTimestamp=
+ActiveEnterTimestampMonotonic=0
+Uptime=7200.50
+`, unitState{LoadState: "loaded", ActiveState: "inactive", SubState: "dead", Age: -1}},
+		// 旧版 systemd 没有 NRestarts
+		{"old systemd", "LoadState=not-found\nActiveState=inactive\nSubState=dead\n",
+			unitState{LoadState: "not-found", ActiveState: "inactive", SubState: "dead", Age: -1}},
+		{"empty", "", unitState{Age: -1}},
+	}
+	for _, tt := range tests {
+		if got := parseUnitState(tt.output); got != tt.want {
+			t.Errorf("%s: parseUnitState = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseListeningPorts(t *testing.T) {
+	output := `State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+LISTEN 0      4096   0.0.0.0:9090        0.0.0.0:*
+LISTEN 0      128    127.0.0.1:9093      0.0.0.0:*
+LISTEN 0      4096   [::]:9100           [::]:*
+LISTEN 0      4096   *:9091              *:*
+LISTEN 0      4096   [::ffff:10.0.0.1]:9094 *:*
+LISTEN 0      128    0.0.0.0%lo:53       0.0.0.0:*
+`
+	want := map[int]bool{9090: true, 9093: true, 9100: true, 9091: true, 9094: true, 53: true}
+	if got := parseListeningPorts(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseListeningPorts = %v, want %v", got, want)
+	}
+	if got := parseListeningPorts(""); len(got) != 0 {
+		t.Errorf("parseListeningPorts of empty output = %v", got)
+	}
+}
@@ -3,24 +3,42 @@ package checker
 import (
 	"fmt"
 	"ops_cli/internal/config"
+	"ops_cli/internal/facts"
 	"ops_cli/pkg/log"
 	"ops_cli/pkg/ssh"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// 如果时间比ops时间快3分钟，则认为时间不同步
-const timeSyncThreshold = 300
+// 未配置 time_sync_threshold 时，节点与 ops 节点的时钟偏差超过3分钟则认为时间不同步
+const defaultTimeSyncThreshold = 3 * time.Minute
 
 type SystemChecker struct {
 	config      *config.Config
-	timeResults map[string]int64 // 存储每个IP的时间戳
+	timeResults map[string]clockOffset // 存储每个IP相对本机的时钟偏差
+	facts       *facts.Cache           // 本次检查使用的主机信息缓存，可能为 nil
+}
+
+// clockOffset 表示远端时钟相对本机的偏差，误差不超过 RTT 的一半
+type clockOffset struct {
+	Offset time.Duration
+	RTT    time.Duration
+}
+
+// ntpStatus 表示从 chronyc 或 timedatectl 解析出的同步状态
+type ntpStatus struct {
+	Tool         string
+	Active       bool
+	Synchronized bool
+	Source       string
+	Stratum      int
 }
 
 func NewSystemChecker(cfg *config.Config) *SystemChecker {
 	return &SystemChecker{
 		config:      cfg,
-		timeResults: make(map[string]int64),
+		timeResults: make(map[string]clockOffset),
 	}
 }
 
@@ -30,10 +48,11 @@ func (s *SystemChecker) Name() string {
 
 func (s *SystemChecker) Check() []CheckResult {
 	var results []CheckResult
+	s.facts, _ = facts.LoadFresh(s.config)
 
 	// 首先检查每个节点的系统时间
 	for _, ip := range s.config.IPs {
-		results = append(results, s.checkSystemTime(ip))
+		results = append(results, s.checkHost(ip)...)
 	}
 
 	// 然后检查时间同步状态
@@ -42,34 +61,87 @@ func (s *SystemChecker) Check() []CheckResult {
 	return results
 }
 
-func (s *SystemChecker) checkSystemTime(ip config.IPConfig) CheckResult {
-	log.Info("Checking system time for %s", ip.IP)
-
+// checkHost 复用同一个 SSH 连接完成单个节点上的所有检查
+func (s *SystemChecker) checkHost(ip config.IPConfig) []CheckResult {
 	client := ssh.NewClient(ip.IP, ip.User, ip.Password, ip.Port)
 	if err := client.Connect(); err != nil {
-		return s.createFailedResult("System Time", ip, "Failed to establish SSH connection", err)
+		return []CheckResult{s.createFailedResult("System Time", ip, "Failed to establish SSH connection", err)}
 	}
 	defer client.Close()
 
-	// 获取系统时间戳
-	output, err := client.RunCommand("date +%s")
+	results := []CheckResult{
+		s.checkSystemTime(client, ip),
+		s.checkNTP(client, ip),
+	}
+	return append(results, s.checkResources(client, ip)...)
+}
+
+func (s *SystemChecker) checkSystemTime(client *ssh.Client, ip config.IPConfig) CheckResult {
+	log.Info("Checking system time for %s", ip.IP)
+
+	// 记录命令前后的本地时间，用中点估计远端取时刻以抵消往返延迟
+	before := time.Now()
+	output, err := client.RunCommand("date +%s.%N")
+	after := time.Now()
 	if err != nil {
 		return s.createFailedResult("System Time", ip, "Failed to get system time", err)
 	}
 
-	timestamp := strings.TrimSpace(output)
-	timestampInt, err := strconv.ParseInt(timestamp, 10, 64)
+	remote, err := parseUnixTime(strings.TrimSpace(output))
 	if err != nil {
 		return s.createFailedResult("System Time", ip, "Failed to parse system time", err)
 	}
 
-	// 存储时间戳用于后续的同步检查
-	s.timeResults[ip.IP] = timestampInt
+	rtt := after.Sub(before)
+	midpoint := before.Add(rtt / 2)
+	offset := clockOffset{Offset: remote.Sub(midpoint), RTT: rtt}
+
+	// 存储偏差用于后续的同步检查
+	s.timeResults[ip.IP] = offset
 
 	result := s.createBaseResult("System Time", ip)
 	result.Status = "Passed"
-	result.Message = fmt.Sprintf("System time: %s", timestamp)
-	log.Info("System time check passed for %s: %s", ip.IP, timestamp)
+	result.Message = fmt.Sprintf("System time: %s, offset to local %s (±%s)",
+		remote.Format("2006-01-02 15:04:05.000"), offset.Offset.Round(time.Millisecond), (rtt / 2).Round(time.Millisecond))
+	log.Info("System time check passed for %s: %s", ip.IP, result.Message)
+
+	return result
+}
+
+func (s *SystemChecker) checkNTP(client *ssh.Client, ip config.IPConfig) CheckResult {
+	log.Info("Checking NTP status for %s", ip.IP)
+
+	var status ntpStatus
+	if output, err := client.RunCommand("chronyc tracking"); err == nil {
+		status = parseChronyTracking(output)
+	} else if output, err := client.RunCommand("timedatectl show"); err == nil {
+		log.Debug("chronyc not available on %s, falling back to timedatectl", ip.IP)
+		status = parseTimedatectl(output)
+	} else {
+		return s.createFailedResult("NTP Status", ip, "Failed to get NTP status from chronyc or timedatectl", err)
+	}
+
+	detail := fmt.Sprintf("via %s", status.Tool)
+	if status.Source != "" {
+		detail += fmt.Sprintf(", source %s", status.Source)
+	}
+	if status.Stratum > 0 {
+		detail += fmt.Sprintf(", stratum %d", status.Stratum)
+	}
+
+	result := s.createBaseResult("NTP Status", ip)
+	switch {
+	case !status.Active:
+		return s.createFailedResult("NTP Status", ip, fmt.Sprintf("NTP is not active (%s)", detail), nil)
+	case !status.Synchronized:
+		result.Status = "Warning"
+		result.Message = fmt.Sprintf("NTP active but not synchronized (%s)", detail)
+		log.Warn("NTP status for %s: %s", ip.IP, result.Message)
+	default:
+		result.Status = "Passed"
+		result.Message = fmt.Sprintf("NTP synchronized (%s)", detail)
+		log.Info("NTP status check passed for %s", ip.IP)
+	}
 
 	return result
 }
@@ -77,16 +149,21 @@ func (s *SystemChecker) checkSystemTime(ip config.IPConfig) CheckResult {
 func (s *SystemChecker) checkTimeSync() CheckResult {
 	log.Info("Checking time synchronization between nodes")
 
-	// 查找 OPS 节点的时间戳
+	threshold := s.config.System.TimeSyncThreshold
+	if threshold <= 0 {
+		threshold = defaultTimeSyncThreshold
+	}
+
+	// 查找 OPS 节点的时钟偏差
 	var opsIP string
-	var opsTimestamp int64
+	var opsOffset clockOffset
 	var opsConfig config.IPConfig
 
 	for _, ip := range s.config.IPs {
 		if ip.Role == "ops" {
-			if timestamp, exists := s.timeResults[ip.IP]; exists {
+			if offset, exists := s.timeResults[ip.IP]; exists {
 				opsIP = ip.IP
-				opsTimestamp = timestamp
+				opsOffset = offset
 				opsConfig = ip
 				break
 			}
@@ -97,15 +174,23 @@ func (s *SystemChecker) checkTimeSync() CheckResult {
 		return s.createFailedResult("Time Sync", opsConfig, "No OPS node time reference found", nil)
 	}
 
-	// 检查其他节点与 OPS 节点的时间差
+	// 检查其他节点与 OPS 节点的时间差，快慢都算不同步
 	var nonSyncIPs []string
+	var maxDiff time.Duration
 	for _, ip := range s.config.IPs {
 		if ip.Role != "ops" {
-			if timestamp, exists := s.timeResults[ip.IP]; exists {
-				timeDiff := timestamp - opsTimestamp
-				if timeDiff > timeSyncThreshold {
-					log.Error("Time difference too large for %s: %d seconds", ip.IP, timeDiff)
-					nonSyncIPs = append(nonSyncIPs, ip.IP)
+			if offset, exists := s.timeResults[ip.IP]; exists {
+				timeDiff := (offset.Offset - opsOffset.Offset).Round(time.Millisecond)
+				absDiff := timeDiff
+				if absDiff < 0 {
+					absDiff = -absDiff
+				}
+				if absDiff > maxDiff {
+					maxDiff = absDiff
+				}
+				if absDiff > threshold {
+					log.Error("Time difference too large for %s: %s", ip.IP, timeDiff)
+					nonSyncIPs = append(nonSyncIPs, fmt.Sprintf("%s (%s)", ip.IP, timeDiff))
 				}
 			}
 		}
@@ -114,17 +199,89 @@ func (s *SystemChecker) checkTimeSync() CheckResult {
 	result := s.createBaseResult("Time Sync", opsConfig)
 	if len(nonSyncIPs) > 0 {
 		result.Status = "Failed"
-		result.Message = fmt.Sprintf("Time not synchronized for nodes: %s", strings.Join(nonSyncIPs, ", "))
+		result.Message = fmt.Sprintf("Time not synchronized (threshold %s) for nodes: %s", threshold, strings.Join(nonSyncIPs, ", "))
 		log.Error("Time synchronization check failed: %s", result.Message)
 	} else {
 		result.Status = "Passed"
-		result.Message = "All nodes are time synchronized"
+		result.Message = fmt.Sprintf("All nodes are time synchronized (max difference %s, threshold %s)", maxDiff, threshold)
 		log.Info("Time synchronization check passed for all nodes")
 	}
 
 	return result
 }
 
+// parseUnixTime 解析 date +%s.%N 的输出，兼容不支持 %N 的系统
+func parseUnixTime(s string) (time.Time, error) {
+	secPart, nsecPart, _ := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(secPart, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// 不支持 %N 时小数部分为 N、%N 或为空，按整秒处理
+	var nsec int64
+	if nsecPart != "" && strings.Trim(nsecPart, "0123456789") == "" {
+		// 补齐或截断到9位纳秒
+		if len(nsecPart) > 9 {
+			nsecPart = nsecPart[:9]
+		}
+		nsecPart += strings.Repeat("0", 9-len(nsecPart))
+		nsec, _ = strconv.ParseInt(nsecPart, 10, 64)
+	}
+
+	return time.Unix(sec, nsec), nil
+}
+
+// parseChronyTracking 解析 chronyc tracking 的输出
+func parseChronyTracking(output string) ntpStatus {
+	status := ntpStatus{Tool: "chronyc", Active: true}
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Reference ID":
+			// 形如 "C0A80101 (ntp.example.com)"
+			if start := strings.Index(value, "("); start >= 0 {
+				status.Source = strings.TrimSuffix(value[start+1:], ")")
+			} else {
+				status.Source = value
+			}
+		case "Stratum":
+			status.Stratum, _ = strconv.Atoi(value)
+		case "Leap status":
+			status.Synchronized = value != "Not synchronised"
+		}
+	}
+
+	return status
+}
+
+// parseTimedatectl 解析 timedatectl show 的 key=value 输出
+func parseTimedatectl(output string) ntpStatus {
+	status := ntpStatus{Tool: "timedatectl"}
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "NTP":
+			status.Active = value == "yes"
+		case "NTPSynchronized":
+			status.Synchronized = value == "yes"
+		}
+	}
+
+	return status
+}
+
 func (s *SystemChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
 	return CheckResult{
 		Component: s.Name(),
@@ -0,0 +1,76 @@
+package checker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseUnixTime(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"1700000000.123456789", time.Unix(1700000000, 123456789), false},
+		{"1700000000.5", time.Unix(1700000000, 500000000), false},
+		{"1700000000.1234567891", time.Unix(1700000000, 123456789), false},
+		{"1700000000", time.Unix(1700000000, 0), false},
+		// 不支持 %N 的 date
+		{"1700000000.N", time.Unix(1700000000, 0), false},
+		{"1700000000.%N", time.Unix(1700000000, 0), false},
+		{"1700000000.", time.Unix(1700000000, 0), false},
+		{"", time.Time{}, true},
+		{"date: invalid format", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseUnixTime(tt.in)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("parseUnixTime(%q) = %v, %v, want %v (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseChronyTracking(t *testing.T) {
+	synced := `Reference ID    : C0A80101 (ntp.example.com)
+Stratum         : 3
+Ref time (UTC)  : Tue Dec 17 08:12:31 2024
+System time     : 0.000012345 seconds fast of NTP time
+Last offset     : +0.000001234 seconds
+Leap status     : Normal
+`
+	unsynced := `Reference ID    : 00000000 ()
+Stratum         : 0
+Ref time (UTC)  : Thu Jan 01 00:00:00 1970
+Leap status     : Not synchronised
+`
+	tests := []struct {
+		output string
+		want   ntpStatus
+	}{
+		{synced, ntpStatus{Tool: "chronyc", Active: true, Synchronized: true, Source: "ntp.example.com", Stratum: 3}},
+		{unsynced, ntpStatus{Tool: "chronyc", Active: true, Synchronized: false, Source: "", Stratum: 0}},
+		{"Reference ID    : 7F7F0101\nLeap status     : Normal\n", ntpStatus{Tool: "chronyc", Active: true, Synchronized: true, Source: "7F7F0101"}},
+	}
+	for _, tt := range tests {
+		if got := parseChronyTracking(tt.output); got != tt.want {
+			t.Errorf("parseChronyTracking(%q) = %+v, want %+v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimedatectl(t *testing.T) {
+	tests := []struct {
+		output string
+		want   ntpStatus
+	}{
+		{"Timezone=Asia/Shanghai\nLocalRTC=no\nCanNTP=yes\nNTP=yes\nNTPSynchronized=yes\n", ntpStatus{Tool: "timedatectl", Active: true, Synchronized: true}},
+		{"NTP=yes\nNTPSynchronized=no\n", ntpStatus{Tool: "timedatectl", Active: true}},
+		{"NTP=no\nNTPSynchronized=no\n", ntpStatus{Tool: "timedatectl"}},
+		{"", ntpStatus{Tool: "timedatectl"}},
+	}
+	for _, tt := range tests {
+		if got := parseTimedatectl(tt.output); got != tt.want {
+			t.Errorf("parseTimedatectl(%q) = %+v, want %+v", tt.output, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package checker
+
+import (
+	"fmt"
+	"net"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"strconv"
+	"time"
+)
+
+// 未配置 timeout 时的默认连接超时
+const defaultTCPTimeout = 5 * time.Second
+
+type TCPChecker struct {
+	config *config.Config
+}
+
+func NewTCPChecker(cfg *config.Config) *TCPChecker {
+	return &TCPChecker{
+		config: cfg,
+	}
+}
+
+func (t *TCPChecker) Name() string {
+	return "tcp"
+}
+
+func (t *TCPChecker) Check() []CheckResult {
+	var results []CheckResult
+
+	for _, ip := range t.config.IPs {
+		for _, check := range t.config.TCPChecks {
+			if !check.AppliesTo(ip.Role) {
+				continue
+			}
+			results = append(results, t.checkPort(ip, check))
+		}
+	}
+
+	return results
+}
+
+func (t *TCPChecker) checkPort(ip config.IPConfig, check config.TCPCheckConfig) CheckResult {
+	host := ip.IP
+	if check.Host != "" {
+		host = expandIP(check.Host, ip.IP)
+	}
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultTCPTimeout
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(check.Port))
+	log.Info("Checking TCP port %s for %s", addr, ip.IP)
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return t.createFailedResult(check.Name, ip, fmt.Sprintf("%s unreachable", addr), err)
+	}
+	latency := time.Since(start)
+	conn.Close()
+
+	result := t.createBaseResult(check.Name, ip)
+	result.Status = "Passed"
+	result.Message = fmt.Sprintf("%s reachable in %s", addr, latency.Round(time.Millisecond))
+	log.Info("TCP check %s passed for %s", check.Name, ip.IP)
+
+	return result
+}
+
+func (t *TCPChecker) createBaseResult(item string, ip config.IPConfig) CheckResult {
+	return CheckResult{
+		Component: t.Name(),
+		Item:      item,
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+}
+
+func (t *TCPChecker) createFailedResult(item string, ip config.IPConfig, message string, err error) CheckResult {
+	result := t.createBaseResult(item, ip)
+	result.Status = "Failed"
+	result.Message = message
+	result.Error = err
+	log.Error("%s check failed for %s: %v", item, ip.IP, err)
+	return result
+}
@@ -8,6 +8,8 @@ type CheckResult struct {
 	Error     error
 	Role      string
 	IP        string
+	// Details 附加在消息下方的明细行
+	Details []string
 }
 
 type Checker interface {
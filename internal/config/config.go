@@ -2,14 +2,27 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	IPs  []IPConfig `mapstructure:"ips"`
-	Port PortConfig `mapstructure:"port"`
-	Log  LogConfig  `mapstructure:"log"`
+	IPs         []IPConfig        `mapstructure:"ips"`
+	Port        PortConfig        `mapstructure:"port"`
+	Log         LogConfig         `mapstructure:"log"`
+	Pushgateway PushgatewayConfig `mapstructure:"pushgateway"`
+	Exporters   []ExporterConfig  `mapstructure:"exporters"`
+	HTTPChecks  []HTTPCheckConfig `mapstructure:"http_checks"`
+	TCPChecks   []TCPCheckConfig  `mapstructure:"tcp_checks"`
+	System      SystemConfig      `mapstructure:"system"`
+	// Services 按角色配置需要运行的 systemd 单元，default 作为兜底
+	Services     map[string][]string `mapstructure:"services"`
+	Certificates CertificateConfig   `mapstructure:"certificates"`
+	Logs         LogScanConfig       `mapstructure:"logs"`
+	Facts        FactsConfig         `mapstructure:"facts"`
+	Drift        DriftConfig         `mapstructure:"drift"`
+	Network      NetworkConfig       `mapstructure:"network"`
 }
 
 type IPConfig struct {
@@ -18,6 +31,8 @@ type IPConfig struct {
 	Password string `mapstructure:"password"`
 	Port     int    `mapstructure:"port"`
 	Role     string `mapstructure:"role"`
+	// Vars 为查询模板中可引用的节点变量
+	Vars map[string]string `mapstructure:"vars"`
 }
 
 type PortConfig struct {
@@ -26,9 +41,10 @@ type PortConfig struct {
 }
 
 type PortDetail struct {
-	Prometheus  int `mapstructure:"prometheus"`
-	Grafana     int `mapstructure:"grafana"`
-	Pushgateway int `mapstructure:"pushgateway"`
+	Prometheus   int `mapstructure:"prometheus"`
+	Grafana      int `mapstructure:"grafana"`
+	Pushgateway  int `mapstructure:"pushgateway"`
+	Alertmanager int `mapstructure:"alertmanager"`
 }
 
 type LogConfig struct {
@@ -36,6 +52,205 @@ type LogConfig struct {
 	File  string `mapstructure:"file"`
 }
 
+// SystemConfig 定义系统检查配置
+type SystemConfig struct {
+	// TimeSyncThreshold 节点与 OPS 节点允许的最大时钟偏差
+	TimeSyncThreshold time.Duration `mapstructure:"time_sync_threshold"`
+	// Thresholds 按角色配置资源阈值，default 作为兜底
+	Thresholds map[string]ResourceThresholds `mapstructure:"thresholds"`
+}
+
+// Threshold 定义告警和严重两级阈值，0 或未配置时沿用上一级的值，负数表示不检查该级别
+type Threshold struct {
+	Warning  float64 `mapstructure:"warning"`
+	Critical float64 `mapstructure:"critical"`
+}
+
+// MountThreshold 定义单个挂载点的磁盘阈值
+type MountThreshold struct {
+	Path      string `mapstructure:"path"`
+	Threshold `mapstructure:",squash"`
+}
+
+// ResourceThresholds 定义资源使用率阈值，除 Load 外均为百分比
+type ResourceThresholds struct {
+	Disk   Threshold `mapstructure:"disk"`
+	Inode  Threshold `mapstructure:"inode"`
+	Memory Threshold `mapstructure:"memory"`
+	Swap   Threshold `mapstructure:"swap"`
+	// Load 为 1 分钟负载除以 CPU 数
+	Load   Threshold        `mapstructure:"load"`
+	FD     Threshold        `mapstructure:"fd"`
+	Mounts []MountThreshold `mapstructure:"mounts"`
+}
+
+// PushgatewayConfig 定义 Pushgateway 检查配置
+type PushgatewayConfig struct {
+	// MaxAge 推送组超过该时长未更新则视为过期
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// ExporterConfig 定义直接抓取的 exporter
+type ExporterConfig struct {
+	Name string `mapstructure:"name"`
+	Port int    `mapstructure:"port"`
+	Path string `mapstructure:"path"`
+	// Roles 为空时对所有角色生效
+	Roles []string `mapstructure:"roles"`
+	// Metrics 抓取结果中必须存在的指标
+	Metrics []string `mapstructure:"metrics"`
+	// BuildInfo 版本指标名，默认为 <name>_build_info
+	BuildInfo string `mapstructure:"build_info"`
+	// Versions 按角色配置期望版本，default 作为兜底
+	Versions map[string]string `mapstructure:"versions"`
+}
+
+// AppliesTo 判断该配置是否适用于指定角色
+func (e ExporterConfig) AppliesTo(role string) bool {
+	return matchRole(e.Roles, role)
+}
+
+// HTTPCheckConfig 定义通用 HTTP 端点检查
+type HTTPCheckConfig struct {
+	Name  string   `mapstructure:"name"`
+	Roles []string `mapstructure:"roles"`
+	// URL 支持 {ip} 占位符
+	URL     string            `mapstructure:"url"`
+	Method  string            `mapstructure:"method"`
+	Headers map[string]string `mapstructure:"headers"`
+	Body    string            `mapstructure:"body"`
+	// ExpectedStatus 为 0 时期望 200
+	ExpectedStatus int    `mapstructure:"expected_status"`
+	BodyRegex      string `mapstructure:"body_regex"`
+	// JSONPath 以点分隔的字段路径，如 data.items.0.status
+	JSONPath   string        `mapstructure:"json_path"`
+	JSONValue  string        `mapstructure:"json_value"`
+	MaxLatency time.Duration `mapstructure:"max_latency"`
+	Timeout    time.Duration `mapstructure:"timeout"`
+}
+
+// AppliesTo 判断该配置是否适用于指定角色
+func (h HTTPCheckConfig) AppliesTo(role string) bool {
+	return matchRole(h.Roles, role)
+}
+
+// TCPCheckConfig 定义 TCP 端口可达性检查
+type TCPCheckConfig struct {
+	Name  string   `mapstructure:"name"`
+	Roles []string `mapstructure:"roles"`
+	// Host 支持 {ip} 占位符，为空时使用节点 IP
+	Host    string        `mapstructure:"host"`
+	Port    int           `mapstructure:"port"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// AppliesTo 判断该配置是否适用于指定角色
+func (t TCPCheckConfig) AppliesTo(role string) bool {
+	return matchRole(t.Roles, role)
+}
+
+// CertificateConfig 定义证书过期检查
+type CertificateConfig struct {
+	WarningDays  int                  `mapstructure:"warning_days"`
+	CriticalDays int                  `mapstructure:"critical_days"`
+	Endpoints    []CertEndpointConfig `mapstructure:"endpoints"`
+	Files        []CertFileConfig     `mapstructure:"files"`
+}
+
+// CertEndpointConfig 定义需要 TLS 握手的端点
+type CertEndpointConfig struct {
+	Name  string   `mapstructure:"name"`
+	Roles []string `mapstructure:"roles"`
+	// Component 使用端口配置中该组件的端口，Address 非空时忽略
+	Component string `mapstructure:"component"`
+	// Address 支持 {ip} 占位符，如 {ip}:3000
+	Address string `mapstructure:"address"`
+	// ServerName 用于 SNI 和主机名校验，为空时不发送 SNI，校验 Address 中的主机名或 IP
+	ServerName string `mapstructure:"server_name"`
+}
+
+// AppliesTo 判断该配置是否适用于指定角色
+func (c CertEndpointConfig) AppliesTo(role string) bool {
+	return matchRole(c.Roles, role)
+}
+
+// CertFileConfig 定义节点上需要检查的 PEM 证书文件
+type CertFileConfig struct {
+	Roles []string `mapstructure:"roles"`
+	Path  string   `mapstructure:"path"`
+	// Hostname 非空时校验证书是否覆盖该主机名
+	Hostname string `mapstructure:"hostname"`
+}
+
+// AppliesTo 判断该配置是否适用于指定角色
+func (c CertFileConfig) AppliesTo(role string) bool {
+	return matchRole(c.Roles, role)
+}
+
+// LogScanConfig 定义远程日志错误扫描
+type LogScanConfig struct {
+	// Since journalctl 扫描的时间窗口
+	Since time.Duration `mapstructure:"since"`
+	// Lines 读取日志文件末尾的行数
+	Lines int `mapstructure:"lines"`
+	// MaxLines 结果中展示的最近匹配行数
+	MaxLines int `mapstructure:"max_lines"`
+	// Patterns 默认错误模式，来源未单独配置时使用
+	Patterns []string          `mapstructure:"patterns"`
+	Sources  []LogSourceConfig `mapstructure:"sources"`
+}
+
+// LogSourceConfig 定义单个组件的日志来源，File 和 Unit 二选一
+type LogSourceConfig struct {
+	Component string   `mapstructure:"component"`
+	Roles     []string `mapstructure:"roles"`
+	File      string   `mapstructure:"file"`
+	Unit      string   `mapstructure:"unit"`
+	Patterns  []string `mapstructure:"patterns"`
+}
+
+// AppliesTo 判断该配置是否适用于指定角色
+func (l LogSourceConfig) AppliesTo(role string) bool {
+	return matchRole(l.Roles, role)
+}
+
+// FactsConfig 定义主机信息缓存
+type FactsConfig struct {
+	// Cache 缓存文件路径
+	Cache string `mapstructure:"cache"`
+	// MaxAge 缓存超过该时长后检查器不再使用
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// DriftConfig 定义同角色节点间的配置漂移检查
+type DriftConfig struct {
+	// Paths 按角色配置需要比较的文件或目录，支持 shell 通配符
+	Paths map[string][]string `mapstructure:"paths"`
+	// Diff 为 true 时拉取漂移文件并输出与多数版本的差异
+	Diff bool `mapstructure:"diff"`
+}
+
+// NetworkConfig 定义节点间网络连通性检查
+type NetworkConfig struct {
+	// Timeout 单个端口的连接超时
+	Timeout time.Duration `mapstructure:"timeout"`
+	// DNSNames 需要在每个节点上解析的域名
+	DNSNames []string `mapstructure:"dns_names"`
+}
+
+// matchRole 角色列表为空时匹配所有角色
+func matchRole(roles []string, role string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 var globalConfig Config
 
 func LoadConfig(cfgFile string) error {
@@ -61,9 +276,10 @@ func GetConfig() *Config {
 
 // Component constants
 const (
-	ComponentPrometheus  = "prometheus"
-	ComponentGrafana     = "grafana"
-	ComponentPushgateway = "pushgateway"
+	ComponentPrometheus   = "prometheus"
+	ComponentGrafana      = "grafana"
+	ComponentPushgateway  = "pushgateway"
+	ComponentAlertmanager = "alertmanager"
 )
 
 // Path constants
@@ -73,6 +289,15 @@ const (
 	PathTargets    = "targets"
 	PathHealth     = "health"
 	PathFederate   = "federate"
+	PathMetrics    = "metrics"
+	PathStatus     = "status"
+	PathSilences   = "silences"
+	PathAlerts     = "alerts"
+	PathBuildInfo  = "buildinfo"
+	PathLabels     = "labels"
+	PathLabel      = "label"
+	PathSeries     = "series"
+	PathMetadata   = "metadata"
 )
 
 // Role constants
@@ -84,6 +309,8 @@ const (
 type ComponentConfig struct {
 	Prefix string
 	Paths  map[string]string
+	// Port 从端口配置中取出该组件的端口
+	Port func(PortDetail) int
 }
 
 // componentConfigs 定义所有组件的配置
@@ -96,13 +323,35 @@ var componentConfigs = map[string]ComponentConfig{
 			PathTargets:    "/api/v1/targets",
 			PathHealth:     "/-/healthy",
 			PathFederate:   "/federate",
+			PathBuildInfo:  "/api/v1/status/buildinfo",
+			PathLabels:     "/api/v1/labels",
+			PathLabel:      "/api/v1/label",
+			PathSeries:     "/api/v1/series",
+			PathMetadata:   "/api/v1/metadata",
 		},
+		Port: func(d PortDetail) int { return d.Prometheus },
 	},
 	ComponentGrafana: {
 		Prefix: "/grafana",
+		Port:   func(d PortDetail) int { return d.Grafana },
 	},
 	ComponentPushgateway: {
 		Prefix: "/pushgateway",
+		Paths: map[string]string{
+			PathHealth:  "/-/healthy",
+			PathMetrics: "/api/v1/metrics",
+		},
+		Port: func(d PortDetail) int { return d.Pushgateway },
+	},
+	ComponentAlertmanager: {
+		Prefix: "/alertmanager",
+		Paths: map[string]string{
+			PathHealth:   "/-/healthy",
+			PathStatus:   "/api/v2/status",
+			PathSilences: "/api/v2/silences",
+			PathAlerts:   "/api/v2/alerts",
+		},
+		Port: func(d PortDetail) int { return d.Alertmanager },
 	},
 }
 
@@ -158,29 +407,36 @@ func GetUrl(ip string, role string, component string, item string) (string, erro
 	return builder.Build()
 }
 
-func GetPort(role string, component string) (int, error) {
-	if role == "ops" {
-		switch component {
-		case "prometheus":
-			return globalConfig.Port.Ops.Prometheus, nil
-		case "grafana":
-			return globalConfig.Port.Ops.Grafana, nil
-		case "pushgateway":
-			return globalConfig.Port.Ops.Pushgateway, nil
-		default:
-			return 0, fmt.Errorf("unknown component: %s", component)
+// ComponentPorts 返回角色下所有配置了端口的组件
+func ComponentPorts(role string) map[string]int {
+	detail := globalConfig.Port.Default
+	if role == RoleOps {
+		detail = globalConfig.Port.Ops
+	}
+
+	ports := make(map[string]int)
+	for component, config := range componentConfigs {
+		if config.Port == nil {
+			continue
+		}
+		if port := config.Port(detail); port > 0 {
+			ports[component] = port
 		}
 	}
+	return ports
+}
 
-	// Default ports
-	switch component {
-	case "prometheus":
-		return globalConfig.Port.Default.Prometheus, nil
-	case "grafana":
-		return globalConfig.Port.Default.Grafana, nil
-	case "pushgateway":
-		return globalConfig.Port.Default.Pushgateway, nil
-	default:
+// GetPort 根据角色返回组件端口
+func GetPort(role string, component string) (int, error) {
+	config, ok := componentConfigs[component]
+	if !ok || config.Port == nil {
 		return 0, fmt.Errorf("unknown component: %s", component)
 	}
+
+	if role == RoleOps {
+		return config.Port(globalConfig.Port.Ops), nil
+	}
+
+	// Default ports
+	return config.Port(globalConfig.Port.Default), nil
 }
@@ -0,0 +1,209 @@
+package facts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/ssh"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 未配置 facts.cache 时的默认缓存路径
+const defaultCachePath = ".ops_cli/facts.json"
+
+// HostFacts 描述单个节点的基础信息
+type HostFacts struct {
+	IP                string    `json:"ip"`
+	Role              string    `json:"role"`
+	Hostname          string    `json:"hostname,omitempty"`
+	Kernel            string    `json:"kernel,omitempty"`
+	OS                string    `json:"os,omitempty"`
+	Arch              string    `json:"arch,omitempty"`
+	CPUs              int       `json:"cpus,omitempty"`
+	MemoryBytes       int64     `json:"memory_bytes,omitempty"`
+	PrometheusVersion string    `json:"prometheus_version,omitempty"`
+	CollectedAt       time.Time `json:"collected_at"`
+	Error             string    `json:"error,omitempty"`
+}
+
+// Cache 是写入本地缓存文件的内容
+type Cache struct {
+	UpdatedAt time.Time   `json:"updated_at"`
+	Hosts     []HostFacts `json:"hosts"`
+}
+
+// Collect 通过 SSH 收集所有节点的信息，withHTTP 为 true 时附带 Prometheus 版本
+func Collect(cfg *config.Config, withHTTP bool) *Cache {
+	cache := &Cache{UpdatedAt: time.Now()}
+	client := &http.Client{Timeout: 60 * time.Second}
+
+	for _, ip := range cfg.IPs {
+		facts := collectHost(ip)
+		if withHTTP {
+			version, err := prometheusVersion(client, ip)
+			if err != nil {
+				log.Warn("Failed to get Prometheus build info for %s: %v", ip.IP, err)
+			}
+			facts.PrometheusVersion = version
+		}
+		cache.Hosts = append(cache.Hosts, facts)
+	}
+
+	return cache
+}
+
+func collectHost(ip config.IPConfig) HostFacts {
+	log.Info("Collecting facts for %s", ip.IP)
+
+	facts := HostFacts{IP: ip.IP, Role: ip.Role, CollectedAt: time.Now()}
+
+	client := ssh.NewClient(ip.IP, ip.User, ip.Password, ip.Port)
+	if err := client.Connect(); err != nil {
+		facts.Error = err.Error()
+		log.Error("Failed to collect facts for %s: %v", ip.IP, err)
+		return facts
+	}
+	defer client.Close()
+
+	// 一次执行所有命令，用分隔行区分输出
+	const sep = "----"
+	commands := []string{
+		"hostname",
+		"uname -r",
+		"uname -m",
+		"nproc",
+		"grep MemTotal /proc/meminfo",
+		// 没有 os-release 时不影响其他信息，子 shell 避免 . 失败时退出
+		"(. /etc/os-release && echo \"$PRETTY_NAME\") 2>/dev/null || true",
+	}
+	output, err := client.RunCommand(strings.Join(commands, "; echo "+sep+"; "))
+	if err != nil {
+		facts.Error = err.Error()
+		log.Error("Failed to collect facts for %s: %v", ip.IP, err)
+		return facts
+	}
+
+	parts := strings.Split(output, sep+"\n")
+	value := func(i int) string {
+		if i < len(parts) {
+			return strings.TrimSpace(parts[i])
+		}
+		return ""
+	}
+
+	facts.Hostname = value(0)
+	facts.Kernel = value(1)
+	facts.Arch = value(2)
+	facts.CPUs, _ = strconv.Atoi(value(3))
+	if fields := strings.Fields(value(4)); len(fields) >= 2 {
+		kb, _ := strconv.ParseInt(fields[1], 10, 64)
+		facts.MemoryBytes = kb * 1024
+	}
+	facts.OS = value(5)
+
+	return facts
+}
+
+func prometheusVersion(client *http.Client, ip config.IPConfig) (string, error) {
+	baseUrl, err := config.GetUrl(ip.IP, ip.Role, config.ComponentPrometheus, config.PathBuildInfo)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Get(baseUrl)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API returned status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var jsonResponse struct {
+		Data struct {
+			Version string `json:"version"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &jsonResponse); err != nil {
+		return "", fmt.Errorf("failed to parse JSON response: %v", err)
+	}
+	return jsonResponse.Data.Version, nil
+}
+
+// CachePath 返回配置的缓存文件路径
+func CachePath(cfg *config.Config) string {
+	if cfg.Facts.Cache != "" {
+		return cfg.Facts.Cache
+	}
+	return defaultCachePath
+}
+
+// Save 将信息写入缓存文件
+func Save(path string, cache *Cache) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write facts cache: %v", err)
+	}
+	return nil
+}
+
+// Load 读取缓存文件
+func Load(path string) (*Cache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache Cache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("failed to parse facts cache: %v", err)
+	}
+	return &cache, nil
+}
+
+// LoadFresh 读取未过期的缓存，供检查器在一次检查中复用
+func LoadFresh(cfg *config.Config) (*Cache, bool) {
+	cache, err := Load(CachePath(cfg))
+	if err != nil {
+		return nil, false
+	}
+	if cfg.Facts.MaxAge > 0 && time.Since(cache.UpdatedAt) > cfg.Facts.MaxAge {
+		log.Debug("Facts cache is older than %s, ignoring", cfg.Facts.MaxAge)
+		return nil, false
+	}
+	return cache, true
+}
+
+// Lookup 查找节点信息，cache 为 nil 时返回 false
+func (c *Cache) Lookup(ip config.IPConfig) (HostFacts, bool) {
+	if c == nil {
+		return HostFacts{}, false
+	}
+	for _, facts := range c.Hosts {
+		if facts.IP == ip.IP && facts.Role == ip.Role && facts.Error == "" {
+			return facts, true
+		}
+	}
+	return HostFacts{}, false
+}
@@ -0,0 +1,165 @@
+package query
+
+import (
+	"fmt"
+	"ops_cli/internal/checker"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/promql"
+	"time"
+)
+
+// AdHocQuery 描述命令行传入的一次性查询，Start 和 End 非零时执行范围查询
+type AdHocQuery struct {
+	Expr  string
+	Time  time.Time
+	Start time.Time
+	End   time.Time
+	// Step 为 0 时按时间窗口自动计算
+	Step time.Duration
+	// SeriesLimit 每个节点最多展示的序列数
+	SeriesLimit int
+	// Export 导出范围查询的完整结果
+	Export ExportConfig
+	// Chart 绘制范围查询结果
+	Chart ChartConfig
+	// Vars 为命令行 --var 传入的模板变量
+	Vars map[string]string
+	// SkipValidate 为 true 时不在本地解析查询，直接发给服务端
+	SkipValidate bool
+}
+
+// IsRange 判断是否为范围查询
+func (q AdHocQuery) IsRange() bool {
+	return !q.Start.IsZero() || !q.End.IsZero()
+}
+
+// SelectHosts 按 IP 和角色筛选节点，两者都为空时返回全部节点
+func SelectHosts(ips []config.IPConfig, hosts []string, roles []string) []config.IPConfig {
+	if len(hosts) == 0 && len(roles) == 0 {
+		return ips
+	}
+
+	var selected []config.IPConfig
+	for _, ip := range ips {
+		if contains(hosts, ip.IP) || contains(roles, ip.Role) {
+			selected = append(selected, ip)
+		}
+	}
+	return selected
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RunAdHoc 在选中的节点上执行查询，每个节点返回一条结果并在明细中列出所有序列，
+// 范围查询开启图表时同时返回每个节点的图表
+func RunAdHoc(q AdHocQuery, hosts []config.IPConfig) ([]checker.CheckResult, []Chart) {
+	client := NewAPIClient()
+
+	component := "query"
+	if q.IsRange() {
+		component = "query_range"
+		step := ResolveStep(q.Start, q.End, q.Step)
+		if q.Step > 0 && step != q.Step {
+			log.Warn("Step %s yields more than %d points, using %s", q.Step, maxRangePoints, step)
+		}
+		q.Step = step
+	}
+
+	var results []checker.CheckResult
+	var charts []Chart
+
+	// 先展开并解析所有节点的查询，有错误时不发送任何请求
+	exprs := make([]string, len(hosts))
+	for i, ip := range hosts {
+		expr, err := expandTemplate(q.Expr, templateVars(ip, q.Vars))
+		if err != nil {
+			results = append(results, checker.CheckResult{
+				Component: component,
+				Item:      q.Expr,
+				Role:      ip.Role,
+				IP:        ip.IP,
+				Status:    "Failed",
+				Message:   fmt.Sprintf("Invalid query template: %v", err),
+			})
+			log.Error("Invalid query template for %s: %v", ip.IP, err)
+			continue
+		}
+		if _, err := checkPromQL(expr, q.IsRange()); q.SkipValidate || promql.IsUnknownFunction(err) {
+			if err != nil {
+				log.Warn("PromQL not checked locally for %s: %v", ip.IP, err)
+			}
+		} else if err != nil {
+			results = append(results, checker.CheckResult{
+				Component: component,
+				Item:      q.Expr,
+				Role:      ip.Role,
+				IP:        ip.IP,
+				Status:    "Failed",
+				Message:   fmt.Sprintf("Invalid PromQL: %v", err),
+				Details:   errorContext(err),
+			})
+			log.Error("Invalid PromQL for %s: %v", ip.IP, err)
+			continue
+		}
+		exprs[i] = expr
+	}
+	if len(results) > 0 {
+		return results, nil
+	}
+
+	for i, ip := range hosts {
+		expr := exprs[i]
+		result := checker.CheckResult{
+			Component: component,
+			Item:      q.Expr,
+			Role:      ip.Role,
+			IP:        ip.IP,
+		}
+
+		var res *Result
+		var err error
+		if q.IsRange() {
+			res, err = client.QueryRange(ip, expr, q.Start, q.End, q.Step)
+		} else {
+			res, err = client.Query(ip, expr, q.Time)
+		}
+		if err != nil {
+			result.Status = "Failed"
+			result.Message = fmt.Sprintf("%s: %v", queryErrorMessage(q.Expr, err), err)
+			log.Error("Ad-hoc query failed for %s: %v", ip.IP, err)
+			results = append(results, result)
+			continue
+		}
+
+		result.Status = "Passed"
+		result.Message = fmt.Sprintf("%d series (%s)", len(res.Series), res.Type)
+		result.Details = formatResult(res, q.SeriesLimit)
+		applyWarnings(&result, res.Warnings)
+
+		if q.IsRange() && q.Chart.Enabled() && len(res.Series) > 0 {
+			charts = append(charts, renderChart(q.Chart, fmt.Sprintf("%s - %s (%s)", expr, ip.IP, ip.Role), res, q.SeriesLimit))
+		}
+
+		if q.IsRange() && q.Export.Enabled() {
+			path, err := exportResult(q.Export, exportTarget{IP: ip, Name: "adhoc", Query: expr}, res)
+			if err != nil {
+				result.Status = "Failed"
+				result.Message = fmt.Sprintf("Export failed: %v", err)
+				log.Error("Export failed for %s: %v", ip.IP, err)
+			} else if path != "" {
+				result.Details = append(result.Details, "exported to "+path)
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, charts
+}
@@ -0,0 +1,267 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"strconv"
+	"time"
+)
+
+// Sample 表示一个时间点上的值
+type Sample struct {
+	Time  time.Time
+	Value float64
+	// Raw 为 Prometheus 返回的原始字符串，string 类型结果只使用该字段
+	Raw string
+}
+
+// Series 表示一条带标签的时间序列，即时查询只有一个样本
+type Series struct {
+	Labels  map[string]string
+	Samples []Sample
+}
+
+// Result 为解析后的查询结果
+type Result struct {
+	Type   string
+	Series []Series
+	// Warnings 为 Prometheus 返回的警告，如部分数据源不可用时的 partial response
+	Warnings []string
+	// Stats 为请求 stats=all 时返回的查询统计，旧版本 Prometheus 不返回
+	Stats *QueryStats
+}
+
+// QueryStats 为 Prometheus 执行查询的耗时（秒）和样本数统计
+type QueryStats struct {
+	Timings struct {
+		EvalTotalTime        float64 `json:"evalTotalTime"`
+		ResultSortTime       float64 `json:"resultSortTime"`
+		QueryPreparationTime float64 `json:"queryPreparationTime"`
+		InnerEvalTime        float64 `json:"innerEvalTime"`
+		ExecQueueTime        float64 `json:"execQueueTime"`
+		ExecTotalTime        float64 `json:"execTotalTime"`
+	} `json:"timings"`
+	Samples struct {
+		TotalQueryableSamples int64 `json:"totalQueryableSamples"`
+		PeakSamples           int64 `json:"peakSamples"`
+	} `json:"samples"`
+}
+
+// APIError 表示 Prometheus 返回的错误响应
+type APIError struct {
+	StatusCode int
+	// Type 为 errorType，如 bad_data、timeout、execution
+	Type    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("API returned status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s (status code %d)", e.Type, e.Message, e.StatusCode)
+}
+
+// IsBadData 判断错误是否由 PromQL 语法或参数错误引起
+func IsBadData(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Type == "bad_data"
+}
+
+// APIClient 封装对 Prometheus HTTP API 的调用
+type APIClient struct {
+	client *http.Client
+	// stats 为 true 时请求 Prometheus 返回查询统计
+	stats bool
+}
+
+func NewAPIClient() *APIClient {
+	return &APIClient{
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Query 在指定时间执行即时查询
+func (c *APIClient) Query(ip config.IPConfig, promql string, ts time.Time) (*Result, error) {
+	params := url.Values{}
+	params.Set("query", promql)
+	params.Set("time", strconv.FormatInt(ts.Unix(), 10))
+	return c.query(ip, config.PathQuery, params)
+}
+
+// QueryRange 在时间范围内按步长执行范围查询
+func (c *APIClient) QueryRange(ip config.IPConfig, promql string, start, end time.Time, step time.Duration) (*Result, error) {
+	params := url.Values{}
+	params.Set("query", promql)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	return c.query(ip, config.PathQueryRange, params)
+}
+
+func (c *APIClient) query(ip config.IPConfig, item string, params url.Values) (*Result, error) {
+	if c.stats {
+		params.Set("stats", "all")
+	}
+	var data struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+		Stats      *QueryStats     `json:"stats"`
+	}
+	warnings, err := c.get(ip, item, params, &data)
+	if err != nil {
+		return nil, err
+	}
+	result, err := parseResult(data.ResultType, data.Result)
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = warnings
+	result.Stats = data.Stats
+	return result, nil
+}
+
+// get 调用 API 并将 data 字段解析到 v，返回响应中的 warnings。
+// 错误响应会解析 errorType 和 error 并以 *APIError 返回
+func (c *APIClient) get(ip config.IPConfig, item string, params url.Values, v interface{}) ([]string, error) {
+	baseUrl, err := config.GetUrl(ip.IP, ip.Role, config.ComponentPrometheus, item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get base url: %v", err)
+	}
+	return c.getURL(ip, baseUrl, params, v)
+}
+
+// getURL 与 get 相同，但直接使用完整的接口地址，用于路径中带参数的接口
+func (c *APIClient) getURL(ip config.IPConfig, baseUrl string, params url.Values, v interface{}) ([]string, error) {
+	url := baseUrl + "?" + params.Encode()
+	log.Info("Making HTTP request to %s with timeout %v", url, c.client.Timeout)
+
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	var jsonResponse struct {
+		Status    string          `json:"status"`
+		Data      json.RawMessage `json:"data"`
+		ErrorType string          `json:"errorType"`
+		Error     string          `json:"error"`
+		Warnings  []string        `json:"warnings"`
+	}
+	if err := json.Unmarshal(body, &jsonResponse); err != nil {
+		// 非 JSON 的错误响应（如反向代理返回的页面）只报告状态码
+		if resp.StatusCode != http.StatusOK {
+			return nil, &APIError{StatusCode: resp.StatusCode}
+		}
+		return nil, fmt.Errorf("failed to parse JSON response: %v", err)
+	}
+	for _, warning := range jsonResponse.Warnings {
+		log.Warn("Prometheus warning from %s: %s", ip.IP, warning)
+	}
+
+	if jsonResponse.Status == "error" || resp.StatusCode != http.StatusOK {
+		return jsonResponse.Warnings, &APIError{
+			StatusCode: resp.StatusCode,
+			Type:       jsonResponse.ErrorType,
+			Message:    jsonResponse.Error,
+		}
+	}
+	if err := json.Unmarshal(jsonResponse.Data, v); err != nil {
+		return nil, fmt.Errorf("failed to parse response data: %v", err)
+	}
+	return jsonResponse.Warnings, nil
+}
+
+// parseResult 解析 vector、matrix、scalar 和 string 四种结果类型
+func parseResult(resultType string, raw json.RawMessage) (*Result, error) {
+	result := &Result{Type: resultType}
+
+	switch resultType {
+	case "vector":
+		var vector []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &vector); err != nil {
+			return nil, fmt.Errorf("failed to parse vector result: %v", err)
+		}
+		for _, v := range vector {
+			sample, err := parseSample(v.Value)
+			if err != nil {
+				return nil, err
+			}
+			result.Series = append(result.Series, Series{Labels: v.Metric, Samples: []Sample{sample}})
+		}
+	case "matrix":
+		var matrix []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"`
+		}
+		if err := json.Unmarshal(raw, &matrix); err != nil {
+			return nil, fmt.Errorf("failed to parse matrix result: %v", err)
+		}
+		for _, m := range matrix {
+			series := Series{Labels: m.Metric}
+			for _, value := range m.Values {
+				sample, err := parseSample(value)
+				if err != nil {
+					return nil, err
+				}
+				series.Samples = append(series.Samples, sample)
+			}
+			result.Series = append(result.Series, series)
+		}
+	case "scalar", "string":
+		var value []interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("failed to parse %s result: %v", resultType, err)
+		}
+		sample, err := parseSample(value)
+		if err != nil {
+			return nil, err
+		}
+		result.Series = []Series{{Samples: []Sample{sample}}}
+	default:
+		return nil, fmt.Errorf("unknown result type %q", resultType)
+	}
+
+	return result, nil
+}
+
+// parseSample 解析 [<unix_time>, "<value>"] 形式的样本
+func parseSample(value []interface{}) (Sample, error) {
+	if len(value) < 2 {
+		return Sample{}, fmt.Errorf("invalid sample %v", value)
+	}
+
+	ts, ok := value[0].(float64)
+	if !ok {
+		return Sample{}, fmt.Errorf("invalid sample timestamp %v", value[0])
+	}
+	raw, ok := value[1].(string)
+	if !ok {
+		return Sample{}, fmt.Errorf("invalid sample value %v", value[1])
+	}
+
+	sample := Sample{
+		Time: time.Unix(0, int64(ts*float64(time.Second))),
+		Raw:  raw,
+	}
+	// string 类型结果无法转换为数值，保留原始字符串
+	if v, err := strconv.ParseFloat(raw, 64); err == nil {
+		sample.Value = v
+	}
+	return sample, nil
+}
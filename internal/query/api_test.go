@@ -0,0 +1,81 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"ops_cli/internal/checker"
+	"ops_cli/internal/config"
+	"testing"
+)
+
+func TestGetURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("query") {
+		case "bad(":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error: unclosed left parenthesis"}`)
+		case "timeout":
+			// 部分实现在出错时仍返回 200
+			fmt.Fprint(w, `{"status":"error","errorType":"timeout","error":"query timed out"}`)
+		case "proxy":
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `<html>502 Bad Gateway</html>`)
+		default:
+			fmt.Fprint(w, `{"status":"success","warnings":["PromQL info: metric might not be a counter"],`+
+				`"data":{"resultType":"vector","result":[{"metric":{"__name__":"up"},"value":[1734534000,"1"]}]}}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewAPIClient()
+	ip := config.IPConfig{IP: "10.0.0.1", Role: "fp"}
+	get := func(query string) ([]string, error) {
+		var data struct {
+			ResultType string `json:"resultType"`
+		}
+		return client.getURL(ip, server.URL, url.Values{"query": {query}}, &data)
+	}
+
+	tests := []struct {
+		query   string
+		typ     string
+		status  int
+		badData bool
+	}{
+		{"bad(", "bad_data", http.StatusBadRequest, true},
+		{"timeout", "timeout", http.StatusOK, false},
+		{"proxy", "", http.StatusBadGateway, false},
+	}
+	for _, tt := range tests {
+		_, err := get(tt.query)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%s: got %v, want *APIError", tt.query, err)
+			continue
+		}
+		if apiErr.Type != tt.typ || apiErr.StatusCode != tt.status || IsBadData(err) != tt.badData {
+			t.Errorf("%s: got %+v, IsBadData %v", tt.query, apiErr, IsBadData(err))
+		}
+	}
+
+	warnings, err := get("up")
+	if err != nil || len(warnings) != 1 {
+		t.Fatalf("got %v, %v", warnings, err)
+	}
+	result := checker.CheckResult{Status: "Passed", Message: "1 series (vector)", Details: []string{"up 1"}}
+	applyWarnings(&result, warnings)
+	if result.Status != "Warning" || result.Message != "1 series (vector) (1 API warnings)" ||
+		len(result.Details) != 2 || result.Details[0] != "warning: PromQL info: metric might not be a counter" {
+		t.Errorf("applyWarnings = %+v", result)
+	}
+
+	// 已失败的结果保持 Failed
+	failed := checker.CheckResult{Status: "Failed", Message: "No data returned"}
+	applyWarnings(&failed, warnings)
+	if failed.Status != "Failed" {
+		t.Errorf("applyWarnings changed Failed to %s", failed.Status)
+	}
+}
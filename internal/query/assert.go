@@ -0,0 +1,243 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"ops_cli/internal/checker"
+	"strings"
+)
+
+// expect 的取值
+const (
+	expectNonEmpty = "non_empty"
+	expectEmpty    = "empty"
+	expectAny      = "any"
+)
+
+// seriesViolation 表示一条超过阈值的序列
+type seriesViolation struct {
+	Labels    map[string]string
+	Value     float64
+	Threshold float64
+	Critical  bool
+}
+
+// applyAssertions 根据查询配置的断言设置结果状态，未配置断言时有数据即通过。
+// 断言配置已在发送请求前由 ValidateQueries 检查
+func applyAssertions(result *checker.CheckResult, query PrometheusQuery, res *Result, limit int) {
+	if limit <= 0 {
+		limit = defaultSeriesLimit
+	}
+	result.Details = formatResult(res, limit)
+
+	expect := query.Expect
+	if expect == "" {
+		expect = expectNonEmpty
+	}
+	switch {
+	case expect == expectEmpty && len(res.Series) > 0:
+		result.Status = "Failed"
+		result.Message = fmt.Sprintf("Expected no data, got %d series", len(res.Series))
+		return
+	case expect == expectEmpty:
+		result.Status = "Passed"
+		result.Message = "No data returned"
+		return
+	case expect == expectNonEmpty && len(res.Series) == 0:
+		result.Status = "Failed"
+		result.Message = "No data returned"
+		return
+	}
+
+	if query.Series != nil && len(res.Series) != *query.Series {
+		result.Status = "Failed"
+		result.Message = fmt.Sprintf("Expected %d series, got %d", *query.Series, len(res.Series))
+		return
+	}
+
+	if query.Warning == nil && query.Critical == nil {
+		result.Status = "Passed"
+		result.Message = summarizeResult(res)
+		return
+	}
+	if res.Type == "string" {
+		result.Status = "Failed"
+		result.Message = "String result cannot be compared with thresholds"
+		return
+	}
+
+	violations := evaluateThresholds(query, res)
+	if len(violations) == 0 {
+		result.Status = "Passed"
+		result.Message = fmt.Sprintf("%s, %d series within thresholds", summarizeResult(res), len(res.Series))
+		return
+	}
+
+	critical := 0
+	for _, v := range violations {
+		if v.Critical {
+			critical++
+		}
+	}
+	if critical > 0 {
+		result.Status = "Failed"
+		result.Message = fmt.Sprintf("%d of %d series breach critical %s %s", critical, len(res.Series), query.operator(), formatValue(*query.Critical))
+	} else {
+		result.Status = "Warning"
+		result.Message = fmt.Sprintf("%d of %d series breach warning %s %s", len(violations), len(res.Series), query.operator(), formatValue(*query.Warning))
+	}
+
+	// 超限的序列放在明细最前面
+	var lines []string
+	for i, v := range violations {
+		if i >= limit {
+			lines = append(lines, fmt.Sprintf("... %d more breaches", len(violations)-limit))
+			break
+		}
+		level := "WARNING"
+		if v.Critical {
+			level = "CRITICAL"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s %s %s %s", level, formatLabels(v.Labels),
+			query.aggregate(res.Type), formatValue(v.Value), query.operator(), formatValue(v.Threshold)))
+	}
+	result.Details = append(lines, result.Details...)
+}
+
+// applyWarnings 将 API 返回的警告放在明细最前面，原本通过的结果降级为 Warning
+func applyWarnings(result *checker.CheckResult, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	if result.Status == "Passed" {
+		result.Status = "Warning"
+	}
+	result.Message = fmt.Sprintf("%s (%d API warnings)", result.Message, len(warnings))
+
+	lines := make([]string, 0, len(warnings)+len(result.Details))
+	for _, warning := range warnings {
+		lines = append(lines, "warning: "+warning)
+	}
+	result.Details = append(lines, result.Details...)
+}
+
+// queryErrorMessage 返回查询失败的说明，PromQL 本身有误时指明查询名
+func queryErrorMessage(name string, err error) string {
+	if IsBadData(err) {
+		return fmt.Sprintf("Invalid PromQL in query %q", name)
+	}
+	return "Query failed"
+}
+
+// evaluateThresholds 逐条序列比较阈值，范围查询先按 aggregate 汇总窗口内的样本
+func evaluateThresholds(query PrometheusQuery, res *Result) []seriesViolation {
+	var violations []seriesViolation
+	for _, series := range res.Series {
+		if len(series.Samples) == 0 {
+			continue
+		}
+
+		value := series.Samples[0].Value
+		if res.Type == "matrix" {
+			value = aggregateSeries(series, query.aggregate(res.Type))
+		}
+		if math.IsNaN(value) {
+			continue
+		}
+
+		switch {
+		case query.Critical != nil && compare(value, query.operator(), *query.Critical):
+			violations = append(violations, seriesViolation{Labels: series.Labels, Value: value, Threshold: *query.Critical, Critical: true})
+		case query.Warning != nil && compare(value, query.operator(), *query.Warning):
+			violations = append(violations, seriesViolation{Labels: series.Labels, Value: value, Threshold: *query.Warning})
+		}
+	}
+	return violations
+}
+
+// aggregateSeries 按 avg、min、max 或 last 汇总序列
+func aggregateSeries(series Series, aggregate string) float64 {
+	stats := computeStats(series)
+	switch aggregate {
+	case "min":
+		return stats.Min
+	case "max":
+		return stats.Max
+	case "last":
+		return stats.Last
+	default:
+		return stats.Avg
+	}
+}
+
+// compare 判断 value 是否满足告警条件 value <op> threshold
+func compare(value float64, op string, threshold float64) bool {
+	switch op {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	}
+	return false
+}
+
+func (q PrometheusQuery) operator() string {
+	if q.Operator == "" {
+		return ">"
+	}
+	return q.Operator
+}
+
+func (q PrometheusQuery) aggregate(resultType string) string {
+	if resultType != "matrix" {
+		return "value"
+	}
+	if q.Aggregate == "" {
+		return "avg"
+	}
+	return q.Aggregate
+}
+
+// validate 检查断言配置是否合法
+func (q PrometheusQuery) validate() error {
+	switch q.operator() {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return fmt.Errorf("unknown operator %q", q.Operator)
+	}
+	switch q.Aggregate {
+	case "", "avg", "min", "max", "last":
+	default:
+		return fmt.Errorf("unknown aggregate %q, expected avg, min, max or last", q.Aggregate)
+	}
+	switch q.Expect {
+	case "", expectNonEmpty, expectEmpty, expectAny:
+	default:
+		return fmt.Errorf("unknown expect %q, expected %s", q.Expect, strings.Join([]string{expectNonEmpty, expectEmpty, expectAny}, ", "))
+	}
+	if q.Series != nil && *q.Series < 0 {
+		return fmt.Errorf("series must not be negative")
+	}
+	// warning 应先于 critical 触发
+	if q.Warning != nil && q.Critical != nil {
+		switch q.operator() {
+		case ">", ">=":
+			if *q.Warning > *q.Critical {
+				return fmt.Errorf("warning %s must not be above critical %s with operator %s", formatValue(*q.Warning), formatValue(*q.Critical), q.operator())
+			}
+		case "<", "<=":
+			if *q.Warning < *q.Critical {
+				return fmt.Errorf("warning %s must not be below critical %s with operator %s", formatValue(*q.Warning), formatValue(*q.Critical), q.operator())
+			}
+		}
+	}
+	return nil
+}
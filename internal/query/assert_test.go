@@ -0,0 +1,78 @@
+package query
+
+import (
+	"ops_cli/internal/checker"
+	"strings"
+	"testing"
+	"time"
+)
+
+func vectorResult(values ...float64) *Result {
+	res := &Result{Type: "vector"}
+	for i, v := range values {
+		res.Series = append(res.Series, Series{
+			Labels:  map[string]string{"instance": string(rune('a' + i))},
+			Samples: []Sample{{Time: time.Unix(0, 0), Value: v, Raw: formatValue(v)}},
+		})
+	}
+	return res
+}
+
+func floatp(v float64) *float64 { return &v }
+
+func intp(v int) *int { return &v }
+
+func TestApplyAssertions(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   PrometheusQuery
+		res     *Result
+		status  string
+		message string
+	}{
+		{"no assertion", PrometheusQuery{}, vectorResult(1), "Passed", "1"},
+		{"no data", PrometheusQuery{}, vectorResult(), "Failed", "No data returned"},
+		{"expect any", PrometheusQuery{Expect: expectAny}, vectorResult(), "Passed", "0 series"},
+		{"expect empty", PrometheusQuery{Expect: expectEmpty}, vectorResult(), "Passed", "No data returned"},
+		{"expect empty got data", PrometheusQuery{Expect: expectEmpty}, vectorResult(1, 2), "Failed", "Expected no data, got 2 series"},
+		{"series count", PrometheusQuery{Series: intp(2)}, vectorResult(1, 2), "Passed", "2 series"},
+		{"series count mismatch", PrometheusQuery{Series: intp(3)}, vectorResult(1, 2), "Failed", "Expected 3 series, got 2"},
+		{"within thresholds", PrometheusQuery{Warning: floatp(80), Critical: floatp(90)}, vectorResult(10, 20), "Passed", "2 series within thresholds"},
+		{"warning", PrometheusQuery{Warning: floatp(80), Critical: floatp(90)}, vectorResult(10, 85), "Warning", "1 of 2 series breach warning > 80"},
+		{"critical", PrometheusQuery{Warning: floatp(80), Critical: floatp(90)}, vectorResult(85, 95), "Failed", "1 of 2 series breach critical > 90"},
+		{"below", PrometheusQuery{Warning: floatp(20), Critical: floatp(10), Operator: "<"}, vectorResult(15, 50), "Warning", "1 of 2 series breach warning < 20"},
+		{"equal", PrometheusQuery{Critical: floatp(0), Operator: "=="}, vectorResult(0, 1), "Failed", "1 of 2 series breach critical == 0"},
+	}
+	for _, tt := range tests {
+		var result checker.CheckResult
+		applyAssertions(&result, tt.query, tt.res, 0)
+		if result.Status != tt.status || !strings.Contains(result.Message, tt.message) {
+			t.Errorf("%s: got %s %q, want %s containing %q", tt.name, result.Status, result.Message, tt.status, tt.message)
+		}
+	}
+}
+
+func TestValidateAssertion(t *testing.T) {
+	tests := []struct {
+		query PrometheusQuery
+		ok    bool
+	}{
+		{PrometheusQuery{}, true},
+		{PrometheusQuery{Warning: floatp(80), Critical: floatp(90)}, true},
+		{PrometheusQuery{Warning: floatp(90), Critical: floatp(90), Operator: ">="}, true},
+		{PrometheusQuery{Warning: floatp(95), Critical: floatp(90)}, false},
+		{PrometheusQuery{Warning: floatp(20), Critical: floatp(10), Operator: "<"}, true},
+		{PrometheusQuery{Warning: floatp(5), Critical: floatp(10), Operator: "<="}, false},
+		{PrometheusQuery{Warning: floatp(1), Critical: floatp(0), Operator: "!="}, true},
+		{PrometheusQuery{Operator: "=>"}, false},
+		{PrometheusQuery{Aggregate: "sum"}, false},
+		{PrometheusQuery{Expect: "some"}, false},
+		{PrometheusQuery{Series: intp(-1)}, false},
+	}
+	for _, tt := range tests {
+		err := tt.query.validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("validate(%+v) = %v, want ok %v", tt.query, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"ops_cli/pkg/promql"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BenchSpec 描述一次压测，Expr 非空时压测该查询，否则压测 query.yaml 中 Type 类型的查询
+type BenchSpec struct {
+	Type string
+	Expr string
+	// Time 为即时查询的执行时间
+	Time time.Time
+	// Start 非零时 Expr 按范围查询执行，配置的 query_range 查询总是使用 Start 和 End
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+	// Runs 为每个查询在每个节点上的执行次数
+	Runs int
+	// Concurrency 为同时执行的请求数
+	Concurrency int
+	// Vars 为命令行 --var 传入的模板变量
+	Vars map[string]string
+	// SkipValidate 为 true 时不在本地解析查询
+	SkipValidate bool
+}
+
+// benchQuery 为节点上待压测的一个查询
+type benchQuery struct {
+	section string
+	name    string
+	query   string
+}
+
+// BenchReport 为一个查询在一个节点上的压测结果
+type BenchReport struct {
+	Section string
+	Name    string
+	IP      config.IPConfig
+	Runs    int
+	Errors  int
+	// LastError 为最后一次失败的原因
+	LastError error
+	// P50、P90、P99 为客户端测得的延迟，包括网络传输和结果解析
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	// Series 和 Samples 为最后一次成功执行返回的序列数和样本数
+	Series  int
+	Samples int
+	// EvalP50 为 Prometheus 统计的执行耗时中位数，未返回统计时为 0
+	EvalP50 time.Duration
+	// PeakSamples 和 QueryableSamples 为各次执行中 Prometheus 统计的最大值
+	PeakSamples      int64
+	QueryableSamples int64
+	HasStats         bool
+}
+
+// ErrorRate 返回失败次数占比
+func (r BenchReport) ErrorRate() float64 {
+	if r.Runs == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Runs)
+}
+
+// RunBench 依次在每个节点上压测每个查询，同一时刻只压测一个节点上的一个查询
+func RunBench(spec BenchSpec, hosts []config.IPConfig) []BenchReport {
+	if spec.Runs <= 0 {
+		spec.Runs = 1
+	}
+	if spec.Concurrency <= 0 {
+		spec.Concurrency = 1
+	}
+	if !spec.Start.IsZero() {
+		spec.Step = ResolveStep(spec.Start, spec.End, spec.Step)
+	}
+
+	client := newBenchClient(spec.Concurrency)
+
+	var reports []BenchReport
+	for _, ip := range hosts {
+		for _, q := range benchQueries(spec, ip) {
+			reports = append(reports, benchHost(client, spec, q, ip))
+		}
+	}
+	return reports
+}
+
+// newBenchClient 返回请求查询统计的客户端，每个主机保留 concurrency 个空闲连接，
+// 避免并发超过默认的 2 个空闲连接时反复建连，使延迟分位数反映的是建连耗时
+func newBenchClient(concurrency int) *APIClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 0
+	transport.MaxIdleConnsPerHost = concurrency
+
+	client := NewAPIClient()
+	client.client.Transport = transport
+	client.stats = true
+	return client
+}
+
+// benchQueries 返回节点上需要压测的查询
+func benchQueries(spec BenchSpec, ip config.IPConfig) []benchQuery {
+	if spec.Expr != "" {
+		section := "query"
+		if !spec.Start.IsZero() {
+			section = "query_range"
+		}
+		return []benchQuery{{section: section, name: spec.Expr, query: spec.Expr}}
+	}
+
+	var queries []benchQuery
+	for _, section := range []string{"query", "query_range"} {
+		if spec.Type != "all" && spec.Type != section {
+			continue
+		}
+		configured, _, _ := loadQueries(section, queryGroup(ip.Role))
+		for _, q := range configured {
+			queries = append(queries, benchQuery{section: section, name: q.Name, query: q.Query})
+		}
+	}
+	return queries
+}
+
+// benchHost 以 spec.Concurrency 的并发在节点上执行 spec.Runs 次查询
+func benchHost(client *APIClient, spec BenchSpec, q benchQuery, ip config.IPConfig) BenchReport {
+	report := BenchReport{Section: q.section, Name: q.name, IP: ip, Runs: spec.Runs}
+
+	expr, err := expandTemplate(q.query, templateVars(ip, spec.Vars))
+	if err != nil {
+		report.Errors = spec.Runs
+		report.LastError = fmt.Errorf("invalid query template: %v", err)
+		return report
+	}
+	if _, err := checkPromQL(expr, q.section == "query_range"); spec.SkipValidate || promql.IsUnknownFunction(err) {
+		if err != nil {
+			log.Warn("PromQL of %s not checked locally for %s: %v", q.name, ip.IP, err)
+		}
+	} else if err != nil {
+		report.Errors = spec.Runs
+		report.LastError = fmt.Errorf("invalid PromQL: %v", err)
+		return report
+	}
+	log.Info("Benchmarking %s on %s: %d runs, concurrency %d", q.name, ip.IP, spec.Runs, spec.Concurrency)
+
+	run := func() (*Result, error) {
+		if q.section == "query_range" {
+			return client.QueryRange(ip, expr, spec.Start, spec.End, spec.Step)
+		}
+		return client.Query(ip, expr, spec.Time)
+	}
+
+	// 预热：并发建立连接，结果不计入统计
+	var wg sync.WaitGroup
+	for i := 0; i < spec.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run()
+		}()
+	}
+	wg.Wait()
+
+	var mu sync.Mutex
+	var latencies, evalTimes []time.Duration
+	sem := make(chan struct{}, spec.Concurrency)
+
+	for i := 0; i < spec.Runs; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			started := time.Now()
+			res, err := run()
+			elapsed := time.Since(started)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Errors++
+				report.LastError = err
+				return
+			}
+			latencies = append(latencies, elapsed)
+			report.Series = len(res.Series)
+			report.Samples = countSamples(res)
+			if res.Stats != nil {
+				report.HasStats = true
+				evalTimes = append(evalTimes, time.Duration(res.Stats.Timings.EvalTotalTime*float64(time.Second)))
+				report.PeakSamples = max64(report.PeakSamples, res.Stats.Samples.PeakSamples)
+				report.QueryableSamples = max64(report.QueryableSamples, res.Stats.Samples.TotalQueryableSamples)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report.P50 = percentile(latencies, 0.5)
+	report.P90 = percentile(latencies, 0.9)
+	report.P99 = percentile(latencies, 0.99)
+	report.EvalP50 = percentile(evalTimes, 0.5)
+	return report
+}
+
+// percentile 按最近秩法返回第 p 分位数，durations 为空时返回 0
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// countSamples 返回结果中的样本总数
+func countSamples(res *Result) int {
+	n := 0
+	for _, series := range res.Series {
+		n += len(series.Samples)
+	}
+	return n
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,96 @@
+package query
+
+import (
+	"fmt"
+	"ops_cli/pkg/chart"
+	"time"
+)
+
+// 图表样式
+const (
+	ChartLine  = "line"
+	ChartSpark = "spark"
+)
+
+// 未配置 chart.height 时折线图的行数
+const defaultChartHeight = 12
+
+// ChartConfig 控制范围查询结果在终端中的绘制，Style 为空时不绘制
+type ChartConfig struct {
+	Style  string `mapstructure:"style"`
+	Height int    `mapstructure:"height"`
+	// Width 为 0 时使用终端宽度
+	Width int `mapstructure:"width"`
+}
+
+// Enabled 判断是否需要绘制图表
+func (c ChartConfig) Enabled() bool {
+	return c.Style != ""
+}
+
+// Validate 检查图表样式是否合法
+func (c ChartConfig) Validate() error {
+	switch c.Style {
+	case "", ChartLine, ChartSpark:
+		return nil
+	}
+	return fmt.Errorf("unknown chart style %q, expected %s or %s", c.Style, ChartLine, ChartSpark)
+}
+
+// Chart 表示一次范围查询绘制出的图表
+type Chart struct {
+	Title string
+	Lines []string
+}
+
+// renderChart 将范围查询结果绘制为折线图或火花线，最多绘制 limit 条序列
+func renderChart(cfg ChartConfig, title string, res *Result, limit int) Chart {
+	if limit <= 0 {
+		limit = defaultSeriesLimit
+	}
+
+	var series []chart.Series
+	var start, end time.Time
+	for i, s := range res.Series {
+		if i >= limit {
+			break
+		}
+		cs := chart.Series{Label: formatLabels(s.Labels)}
+		for _, sample := range s.Samples {
+			if start.IsZero() || sample.Time.Before(start) {
+				start = sample.Time
+			}
+			if sample.Time.After(end) {
+				end = sample.Time
+			}
+			cs.X = append(cs.X, unixSeconds(sample.Time))
+			cs.Y = append(cs.Y, sample.Value)
+		}
+		series = append(series, cs)
+	}
+
+	// 跨天时横轴带上日期
+	layout := "15:04:05"
+	if end.Sub(start) >= 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	formatX := func(x float64) string {
+		return time.Unix(0, int64(x*float64(time.Second))).In(timeLocation).Format(layout)
+	}
+
+	height := cfg.Height
+	if height <= 0 {
+		height = defaultChartHeight
+	}
+
+	var lines []string
+	if cfg.Style == ChartSpark {
+		lines = chart.Sparkline(series, cfg.Width)
+	} else {
+		lines = chart.Line(series, cfg.Width, height, formatX)
+	}
+	if len(res.Series) > limit {
+		lines = append(lines, fmt.Sprintf("... %d more series not drawn", len(res.Series)-limit))
+	}
+	return Chart{Title: title, Lines: lines}
+}
@@ -0,0 +1,261 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"ops_cli/internal/checker"
+	"ops_cli/internal/config"
+	"sort"
+)
+
+// 未配置 compare.ignore_labels 时忽略的标签，通常每个节点不同
+var defaultCompareIgnoreLabels = []string{"instance"}
+
+// percent 和 absolute 都未配置时使用的百分比阈值
+const defaultComparePercent = 10
+
+// CompareConfig 控制跨节点对比，Percent 和 Absolute 都为 0 时按 10% 判断离群
+type CompareConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Percent 为偏离中位数的百分比阈值
+	Percent float64 `mapstructure:"percent"`
+	// Absolute 为偏离中位数的绝对值阈值
+	Absolute float64 `mapstructure:"absolute"`
+	// IgnoreLabels 对齐序列时忽略的标签
+	IgnoreLabels []string `mapstructure:"ignore_labels"`
+}
+
+// observation 记录一个节点上一次查询的原始结果
+type observation struct {
+	Component string
+	IP        config.IPConfig
+	Query     PrometheusQuery
+	Result    *Result
+}
+
+// group 返回查询所属的配置分组，同组的节点执行相同的查询
+func (o observation) group() string {
+	return queryGroup(o.IP.Role)
+}
+
+// queryGroup 返回角色对应的配置分组，ops 之外的角色都使用 general
+func queryGroup(role string) string {
+	if role == "ops" {
+		return "ops"
+	}
+	return "general"
+}
+
+// Comparison 表示同一查询在各节点上按标签集合对齐后的结果
+type Comparison struct {
+	Title string
+	Hosts []string
+	Rows  []ComparisonRow
+}
+
+// ComparisonRow 表示一个标签集合在各节点上的值，缺失的节点不在 Values 中
+type ComparisonRow struct {
+	Labels   string
+	Median   float64
+	Values   map[string]float64
+	Outliers map[string]bool
+}
+
+// compareObservations 按组件、分组和查询名对齐各节点的结果，返回对比表和离群结果
+func compareObservations(cfg CompareConfig, observations []observation) ([]Comparison, []checker.CheckResult) {
+	ignore := cfg.IgnoreLabels
+	if len(ignore) == 0 {
+		ignore = defaultCompareIgnoreLabels
+	}
+	if cfg.Percent <= 0 && cfg.Absolute <= 0 {
+		cfg.Percent = defaultComparePercent
+	}
+
+	// 保持执行顺序
+	var keys []string
+	grouped := make(map[string][]observation)
+	for _, o := range observations {
+		key := fmt.Sprintf("%s/%s %s", o.Component, o.group(), o.Query.Name)
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], o)
+	}
+
+	var comparisons []Comparison
+	var results []checker.CheckResult
+	for _, key := range keys {
+		obs := grouped[key]
+		if len(obs) < 2 {
+			continue
+		}
+		comparison := compareQuery(cfg, key, obs, ignore)
+		comparisons = append(comparisons, comparison)
+		results = append(results, comparisonResults(comparison, obs[0])...)
+	}
+	return comparisons, results
+}
+
+func compareQuery(cfg CompareConfig, title string, obs []observation, ignore []string) Comparison {
+	comparison := Comparison{Title: title}
+	values := make(map[string]map[string]float64) // 标签集合 -> 节点 -> 值
+	var labelKeys []string
+
+	for _, o := range obs {
+		comparison.Hosts = append(comparison.Hosts, o.IP.IP)
+		for _, series := range o.Result.Series {
+			if len(series.Samples) == 0 {
+				continue
+			}
+			value := series.Samples[0].Value
+			if o.Result.Type == "matrix" {
+				value = aggregateSeries(series, o.Query.aggregate(o.Result.Type))
+			}
+
+			// 去掉忽略的标签后相同的序列求和，如各 target 的 up
+			labels := formatLabels(withoutLabels(series.Labels, ignore))
+			if _, ok := values[labels]; !ok {
+				values[labels] = make(map[string]float64)
+				labelKeys = append(labelKeys, labels)
+			}
+			values[labels][o.IP.IP] += value
+		}
+	}
+	sort.Strings(labelKeys)
+
+	for _, labels := range labelKeys {
+		row := ComparisonRow{
+			Labels:   labels,
+			Values:   values[labels],
+			Outliers: make(map[string]bool),
+		}
+		var present []float64
+		for _, v := range row.Values {
+			if !math.IsNaN(v) {
+				present = append(present, v)
+			}
+		}
+		row.Median = median(present)
+
+		for _, host := range comparison.Hosts {
+			v, ok := row.Values[host]
+			// 其他节点有而该节点缺失的序列也视为离群
+			row.Outliers[host] = !ok || isOutlier(cfg, v, row.Median)
+		}
+		comparison.Rows = append(comparison.Rows, row)
+	}
+	return comparison
+}
+
+// comparisonResults 为每个离群节点生成一条 Warning，没有离群时生成一条 Passed
+func comparisonResults(comparison Comparison, sample observation) []checker.CheckResult {
+	byHost := make(map[string][]string)
+	for _, row := range comparison.Rows {
+		for _, host := range comparison.Hosts {
+			if !row.Outliers[host] {
+				continue
+			}
+			v, ok := row.Values[host]
+			if !ok {
+				byHost[host] = append(byHost[host], fmt.Sprintf("%s missing, median %s", row.Labels, formatValue(row.Median)))
+				continue
+			}
+			byHost[host] = append(byHost[host], fmt.Sprintf("%s = %s, median %s (%s)",
+				row.Labels, formatValue(v), formatValue(row.Median), formatDeviation(v, row.Median)))
+		}
+	}
+
+	if len(byHost) == 0 {
+		return []checker.CheckResult{{
+			Component: "compare",
+			Item:      comparison.Title,
+			Role:      sample.group(),
+			Status:    "Passed",
+			Message:   fmt.Sprintf("%d series consistent across %d hosts", len(comparison.Rows), len(comparison.Hosts)),
+		}}
+	}
+
+	var results []checker.CheckResult
+	for _, host := range comparison.Hosts {
+		details, ok := byHost[host]
+		if !ok {
+			continue
+		}
+		results = append(results, checker.CheckResult{
+			Component: "compare",
+			Item:      comparison.Title,
+			Role:      sample.group(),
+			IP:        host,
+			Status:    "Warning",
+			Message:   fmt.Sprintf("%d of %d series deviate from the fleet median", len(details), len(comparison.Rows)),
+			Details:   details,
+		})
+	}
+	return results
+}
+
+// isOutlier 判断值是否偏离中位数超过百分比或绝对值阈值
+func isOutlier(cfg CompareConfig, v, median float64) bool {
+	if math.IsNaN(v) || math.IsNaN(median) {
+		return false
+	}
+	diff := math.Abs(v - median)
+	if cfg.Absolute > 0 && diff > cfg.Absolute {
+		return true
+	}
+	if cfg.Percent > 0 && diff > 0 {
+		if median == 0 {
+			return true
+		}
+		return diff/math.Abs(median)*100 > cfg.Percent
+	}
+	return false
+}
+
+func formatDeviation(v, median float64) string {
+	diff := v - median
+	if median == 0 {
+		return fmt.Sprintf("%+g", diff)
+	}
+	return fmt.Sprintf("%+g, %+.1f%%", diff, diff/math.Abs(median)*100)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func withoutLabels(labels map[string]string, ignore []string) map[string]string {
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if !contains(ignore, k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// FormatCell 返回对比表中某个节点的单元格内容
+func (r ComparisonRow) FormatCell(host string) string {
+	v, ok := r.Values[host]
+	if !ok {
+		return "missing"
+	}
+	if r.Outliers[host] {
+		return fmt.Sprintf("%s (%s)", formatValue(v), formatDeviation(v, r.Median))
+	}
+	return formatValue(v)
+}
+
+// FormatMedian 返回中位数的展示形式
+func (r ComparisonRow) FormatMedian() string {
+	return formatValue(r.Median)
+}
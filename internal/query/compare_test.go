@@ -0,0 +1,111 @@
+package query
+
+import (
+	"math"
+	"ops_cli/internal/config"
+	"testing"
+	"time"
+)
+
+func TestIsOutlier(t *testing.T) {
+	percent := CompareConfig{Percent: 10}
+	absolute := CompareConfig{Absolute: 5}
+	tests := []struct {
+		cfg    CompareConfig
+		v      float64
+		median float64
+		want   bool
+	}{
+		{percent, 105, 100, false},
+		{percent, 111, 100, true},
+		{percent, 89, 100, true},
+		{percent, 0, 0, false},
+		{percent, 1, 0, true},
+		{percent, math.NaN(), 100, false},
+		{percent, 100, math.NaN(), false},
+		{absolute, 104, 100, false},
+		{absolute, 106, 100, true},
+		{absolute, 3, 0, false},
+		{CompareConfig{Percent: 10, Absolute: 5}, 1006, 1000, true},
+	}
+	for _, tt := range tests {
+		if got := isOutlier(tt.cfg, tt.v, tt.median); got != tt.want {
+			t.Errorf("isOutlier(%+v, %v, %v) = %v, want %v", tt.cfg, tt.v, tt.median, got, tt.want)
+		}
+	}
+}
+
+func compareObservation(ip string, series map[string]float64) observation {
+	res := &Result{Type: "vector"}
+	for mode, v := range series {
+		res.Series = append(res.Series, Series{
+			Labels:  map[string]string{"__name__": "cpu", "mode": mode, "instance": ip + ":9100"},
+			Samples: []Sample{{Time: time.Unix(0, 0), Value: v}},
+		})
+	}
+	return observation{
+		Component: "query",
+		IP:        config.IPConfig{IP: ip, Role: "fp"},
+		Query:     PrometheusQuery{Name: "cpu"},
+		Result:    res,
+	}
+}
+
+func TestCompareObservations(t *testing.T) {
+	observations := []observation{
+		compareObservation("10.0.0.1", map[string]float64{"user": 10, "idle": 0}),
+		compareObservation("10.0.0.2", map[string]float64{"user": 10, "idle": 0}),
+		compareObservation("10.0.0.3", map[string]float64{"user": 20, "idle": 0}),
+		compareObservation("10.0.0.4", map[string]float64{"user": 10}),
+	}
+	// 只有一个节点的查询不参与对比
+	single := compareObservation("10.0.0.5", map[string]float64{"user": 1})
+	single.Query.Name = "single"
+	observations = append(observations, single)
+
+	comparisons, results := compareObservations(CompareConfig{}, observations)
+	if len(comparisons) != 1 {
+		t.Fatalf("got %d comparisons, want 1", len(comparisons))
+	}
+	comparison := comparisons[0]
+	if comparison.Title != "query/general cpu" || len(comparison.Rows) != 2 {
+		t.Fatalf("got %q with %d rows", comparison.Title, len(comparison.Rows))
+	}
+
+	// instance 标签被忽略，行按标签排序
+	idle, user := comparison.Rows[0], comparison.Rows[1]
+	if idle.Labels != `cpu{mode="idle"}` || user.Labels != `cpu{mode="user"}` {
+		t.Fatalf("rows = %q, %q", idle.Labels, user.Labels)
+	}
+	// 中位数为 0 时相同的值不算离群，缺失的序列算离群
+	if idle.Median != 0 || idle.Outliers["10.0.0.1"] || !idle.Outliers["10.0.0.4"] {
+		t.Errorf("idle row: median %v, outliers %v", idle.Median, idle.Outliers)
+	}
+	if got := idle.FormatCell("10.0.0.4"); got != "missing" {
+		t.Errorf("missing cell = %q", got)
+	}
+	if user.Median != 10 || !user.Outliers["10.0.0.3"] || user.Outliers["10.0.0.1"] {
+		t.Errorf("user row: median %v, outliers %v", user.Median, user.Outliers)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(results), results)
+	}
+	if results[0].IP != "10.0.0.3" || results[0].Status != "Warning" || results[0].Details[0] != `cpu{mode="user"} = 20, median 10 (+10, +100.0%)` {
+		t.Errorf("result = %+v", results[0])
+	}
+	if results[1].IP != "10.0.0.4" || results[1].Details[0] != `cpu{mode="idle"} missing, median 0` {
+		t.Errorf("result = %+v", results[1])
+	}
+}
+
+func TestCompareObservationsConsistent(t *testing.T) {
+	observations := []observation{
+		compareObservation("10.0.0.1", map[string]float64{"user": 100}),
+		compareObservation("10.0.0.2", map[string]float64{"user": 105}),
+	}
+	_, results := compareObservations(CompareConfig{}, observations)
+	if len(results) != 1 || results[0].Status != "Passed" {
+		t.Errorf("results = %+v", results)
+	}
+}
@@ -0,0 +1,325 @@
+package query
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 导出格式
+const (
+	ExportCSV         = "csv"
+	ExportJSON        = "json"
+	ExportOpenMetrics = "openmetrics"
+)
+
+// CSV 布局：wide 每条序列一列，long 每个样本一行
+const (
+	LayoutWide = "wide"
+	LayoutLong = "long"
+)
+
+// ExportConfig 控制范围查询结果的导出，Dir 为空时写到标准输出，此时只能导出一个结果
+type ExportConfig struct {
+	Format string `mapstructure:"format"`
+	Layout string `mapstructure:"layout"`
+	Dir    string `mapstructure:"dir"`
+}
+
+// Enabled 判断是否需要导出
+func (e ExportConfig) Enabled() bool {
+	return e.Format != ""
+}
+
+// Validate 检查导出格式和布局是否合法
+func (e ExportConfig) Validate() error {
+	switch e.Format {
+	case "", ExportJSON, ExportOpenMetrics:
+	case ExportCSV:
+		switch e.Layout {
+		case "", LayoutWide, LayoutLong:
+		default:
+			return fmt.Errorf("unknown csv layout %q, expected %s or %s", e.Layout, LayoutWide, LayoutLong)
+		}
+	default:
+		return fmt.Errorf("unknown export format %q, expected %s, %s or %s", e.Format, ExportCSV, ExportJSON, ExportOpenMetrics)
+	}
+	return nil
+}
+
+// ToStdout 判断导出数据是否写到标准输出
+func (e ExportConfig) ToStdout() bool {
+	return e.Enabled() && e.Dir == ""
+}
+
+// CheckTargets 导出到标准输出时只允许一个结果，多个节点或查询的数据拼接后无法区分来源，
+// CSV 会出现多个表头，OpenMetrics 会重复 # TYPE 和 # EOF
+func (e ExportConfig) CheckTargets(targets int) error {
+	if e.ToStdout() && targets > 1 {
+		return fmt.Errorf("cannot export %d results to stdout, select one host and one query or set --export-dir", targets)
+	}
+	return nil
+}
+
+// RedirectLogs 导出到标准输出时将日志切换到标准错误，标准输出只包含导出数据
+func (e ExportConfig) RedirectLogs() {
+	if e.ToStdout() {
+		log.SetConsole(os.Stderr)
+	}
+}
+
+// exportTarget 描述一次导出对应的节点和查询
+type exportTarget struct {
+	IP    config.IPConfig
+	Name  string
+	Query string
+}
+
+// exportResult 按配置导出查询结果，返回写入的文件路径，写到标准输出时返回空字符串
+func exportResult(cfg ExportConfig, target exportTarget, res *Result) (string, error) {
+	if cfg.Dir == "" {
+		// 数据来源记录在日志中，不写入导出数据
+		log.Info("Exporting %s from %s (%s) to stdout", target.Name, target.IP.IP, target.IP.Role)
+		return "", writeExport(os.Stdout, cfg, target, res)
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %v", err)
+	}
+	path := filepath.Join(cfg.Dir, exportFileName(cfg, target))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %v", err)
+	}
+	defer f.Close()
+
+	if err := writeExport(f, cfg, target, res); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// exportFileName 返回 <ip>_<role>_<查询名>.<扩展名>，同一 IP 可能配置多个角色
+func exportFileName(cfg ExportConfig, target exportTarget) string {
+	ext := cfg.Format
+	if ext == ExportOpenMetrics {
+		ext = "om"
+	}
+	return fmt.Sprintf("%s_%s_%s.%s", target.IP.IP, target.IP.Role, sanitizeFileName(target.Name), ext)
+}
+
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// writeExport 写出一次查询的结果，只包含数据
+func writeExport(w io.Writer, cfg ExportConfig, target exportTarget, res *Result) error {
+	switch cfg.Format {
+	case ExportCSV:
+		if cfg.Layout == LayoutLong {
+			return writeCSVLong(w, res)
+		}
+		return writeCSVWide(w, res)
+	case ExportJSON:
+		return writeJSON(w, target, res)
+	case ExportOpenMetrics:
+		return writeOpenMetrics(w, target, res)
+	}
+	return fmt.Errorf("unknown export format %q", cfg.Format)
+}
+
+// writeCSVWide 每个时间点一行，每条序列一列，缺失的点留空
+func writeCSVWide(w io.Writer, res *Result) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"timestamp"}
+	values := make([]map[int64]string, len(res.Series))
+	stamps := make(map[int64]time.Time)
+	for i, series := range res.Series {
+		header = append(header, formatLabels(series.Labels))
+		values[i] = make(map[int64]string)
+		for _, sample := range series.Samples {
+			key := sample.Time.UnixNano()
+			values[i][key] = sample.Raw
+			stamps[key] = sample.Time
+		}
+	}
+
+	keys := make([]int64, 0, len(stamps))
+	for key := range stamps {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	cw.Write(header)
+	for _, key := range keys {
+		row := []string{formatExportTime(stamps[key])}
+		for i := range res.Series {
+			row = append(row, values[i][key])
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeCSVLong 每个样本一行，每个标签一列
+func writeCSVLong(w io.Writer, res *Result) error {
+	cw := csv.NewWriter(w)
+
+	nameSet := make(map[string]bool)
+	for _, series := range res.Series {
+		for name := range series.Labels {
+			nameSet[name] = true
+		}
+	}
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cw.Write(append(append([]string{"timestamp"}, names...), "value"))
+	for _, series := range res.Series {
+		for _, sample := range series.Samples {
+			row := []string{formatExportTime(sample.Time)}
+			for _, name := range names {
+				row = append(row, series.Labels[name])
+			}
+			cw.Write(append(row, sample.Raw))
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON 按 Prometheus API 的 matrix 结构输出，并附带节点和查询信息
+func writeJSON(w io.Writer, target exportTarget, res *Result) error {
+	type jsonSeries struct {
+		Metric map[string]string `json:"metric"`
+		Values [][2]interface{}  `json:"values"`
+	}
+	doc := struct {
+		Host       string       `json:"host"`
+		Role       string       `json:"role"`
+		Name       string       `json:"name"`
+		Query      string       `json:"query"`
+		ResultType string       `json:"resultType"`
+		Result     []jsonSeries `json:"result"`
+	}{
+		Host:       target.IP.IP,
+		Role:       target.IP.Role,
+		Name:       target.Name,
+		Query:      target.Query,
+		ResultType: res.Type,
+		Result:     []jsonSeries{},
+	}
+
+	for _, series := range res.Series {
+		s := jsonSeries{Metric: series.Labels, Values: [][2]interface{}{}}
+		if s.Metric == nil {
+			s.Metric = map[string]string{}
+		}
+		for _, sample := range series.Samples {
+			s.Values = append(s.Values, [2]interface{}{unixSeconds(sample.Time), sample.Raw})
+		}
+		doc.Result = append(doc.Result, s)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// writeOpenMetrics 输出 OpenMetrics 文本格式，没有指标名的序列使用查询名
+func writeOpenMetrics(w io.Writer, target exportTarget, res *Result) error {
+	// 同名指标必须连续输出
+	var names []string
+	byName := make(map[string][]Series)
+	for _, series := range res.Series {
+		name := series.Labels["__name__"]
+		if name == "" {
+			name = metricName(target.Name)
+		}
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], series)
+	}
+
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "# TYPE %s unknown\n", name); err != nil {
+			return err
+		}
+		for _, series := range byName[name] {
+			labels := openMetricsLabels(series.Labels)
+			for _, sample := range series.Samples {
+				fmt.Fprintf(w, "%s%s %s %s\n", name, labels, sample.Raw, strconv.FormatFloat(unixSeconds(sample.Time), 'f', -1, 64))
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, "# EOF")
+	return err
+}
+
+func openMetricsLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, replacer.Replace(labels[k])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// metricName 将查询名转换为合法的指标名，无法转换时使用 query_result
+func metricName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || r == '_' || r == ':'):
+			b.WriteRune(r)
+		case r < unicode.MaxASCII && unicode.IsDigit(r) && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if strings.Trim(b.String(), "_") == "" {
+		return "query_result"
+	}
+	return b.String()
+}
+
+func formatExportTime(t time.Time) string {
+	return t.In(timeLocation).Format(time.RFC3339)
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
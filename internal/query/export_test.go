@@ -0,0 +1,130 @@
+package query
+
+import (
+	"bytes"
+	"io"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportFixture() (exportTarget, *Result) {
+	t0 := time.Date(2024, 12, 18, 15, 0, 0, 0, time.UTC)
+	target := exportTarget{IP: config.IPConfig{IP: "10.0.0.1", Role: "fp"}, Name: "cpu usage", Query: "rate(cpu[5m])"}
+	res := &Result{Type: "matrix", Series: []Series{
+		{Labels: map[string]string{"__name__": "cpu", "mode": "user"}, Samples: []Sample{
+			{Time: t0, Raw: "1"},
+			{Time: t0.Add(time.Minute), Raw: "2"},
+		}},
+		{Labels: map[string]string{"__name__": "cpu", "mode": "system"}, Samples: []Sample{
+			{Time: t0.Add(time.Minute), Raw: "0.5"},
+		}},
+	}}
+	return target, res
+}
+
+func TestWriteExport(t *testing.T) {
+	target, res := exportFixture()
+
+	tests := []struct {
+		cfg  ExportConfig
+		want string
+	}{
+		{ExportConfig{Format: ExportCSV}, `timestamp,"cpu{mode=""user""}","cpu{mode=""system""}"
+2024-12-18T23:00:00+08:00,1,
+2024-12-18T23:01:00+08:00,2,0.5
+`},
+		{ExportConfig{Format: ExportCSV, Layout: LayoutLong}, `timestamp,__name__,mode,value
+2024-12-18T23:00:00+08:00,cpu,user,1
+2024-12-18T23:01:00+08:00,cpu,user,2
+2024-12-18T23:01:00+08:00,cpu,system,0.5
+`},
+		{ExportConfig{Format: ExportOpenMetrics}, `# TYPE cpu unknown
+cpu{mode="user"} 1 1734534000
+cpu{mode="user"} 2 1734534060
+cpu{mode="system"} 0.5 1734534060
+# EOF
+`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeExport(&buf, tt.cfg, target, res); err != nil {
+			t.Fatalf("%s/%s: %v", tt.cfg.Format, tt.cfg.Layout, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s/%s:\ngot:\n%s\nwant:\n%s", tt.cfg.Format, tt.cfg.Layout, got, tt.want)
+		}
+	}
+}
+
+// 导出到标准输出时，标准输出只能包含导出数据，日志写到标准错误
+func TestExportToStdoutDataOnly(t *testing.T) {
+	stdout, stderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	log.InitLogger()
+	log.EnableColor(false)
+	cfg := ExportConfig{Format: ExportCSV}
+	cfg.RedirectLogs()
+	defer log.SetConsole(stdout)
+
+	target, res := exportFixture()
+	log.Info("Checking Prometheus query range for %s", target.IP.IP)
+	path, err := exportResult(cfg, target, res)
+	outW.Close()
+	errW.Close()
+	if err != nil || path != "" {
+		t.Fatalf("exportResult = %q, %v", path, err)
+	}
+
+	out, _ := io.ReadAll(outR)
+	logs, _ := io.ReadAll(errR)
+	var want bytes.Buffer
+	writeExport(&want, cfg, target, res)
+	if string(out) != want.String() {
+		t.Errorf("stdout:\n%s\nwant only the CSV data:\n%s", out, want.String())
+	}
+	if !strings.Contains(string(logs), "Checking Prometheus query range") || !strings.Contains(string(logs), "Exporting cpu usage from 10.0.0.1") {
+		t.Errorf("stderr missing log lines:\n%s", logs)
+	}
+}
+
+// 多个节点的结果不能拼接到标准输出，写到目录时每个结果一个文件
+func TestExportTwoTargets(t *testing.T) {
+	first, res := exportFixture()
+	second := first
+	second.IP = config.IPConfig{IP: "10.0.0.2", Role: "fp"}
+
+	if err := (ExportConfig{Format: ExportOpenMetrics}).CheckTargets(2); err == nil {
+		t.Error("CheckTargets(2) to stdout succeeded, want an error")
+	}
+	if err := (ExportConfig{Format: ExportOpenMetrics}).CheckTargets(1); err != nil {
+		t.Errorf("CheckTargets(1) to stdout: %v", err)
+	}
+
+	cfg := ExportConfig{Format: ExportOpenMetrics, Dir: t.TempDir()}
+	if err := cfg.CheckTargets(2); err != nil {
+		t.Fatalf("CheckTargets(2) to a directory: %v", err)
+	}
+	var want bytes.Buffer
+	writeExport(&want, cfg, first, res)
+	for _, target := range []exportTarget{first, second} {
+		path, err := exportResult(cfg, target, res)
+		if err != nil {
+			t.Fatalf("exportResult(%s): %v", target.IP.IP, err)
+		}
+		if !strings.HasSuffix(path, target.IP.IP+"_fp_cpu_usage.om") {
+			t.Errorf("path = %s", path)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != want.String() {
+			t.Errorf("%s:\n%s\nwant:\n%s", path, data, want.String())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package query
+
+import (
+	"fmt"
+	"ops_cli/pkg/chart"
+	"sort"
+	"strings"
+	"time"
+)
+
+// formatLabels 将标签格式化为 name{k="v", ...} 形式
+func formatLabels(labels map[string]string) string {
+	name := labels["__name__"]
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+
+	if len(pairs) == 0 {
+		if name == "" {
+			return "{}"
+		}
+		return name
+	}
+	return name + "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// formatSample 格式化单个样本的值和时间
+func formatSample(sample Sample) string {
+	return fmt.Sprintf("%s @ %s", sample.Raw, FormatTime(sample.Time))
+}
+
+// FormatTime 按配置的时区格式化时间
+func FormatTime(t time.Time) string {
+	return t.In(timeLocation).Format(timeLayout)
+}
+
+// 未配置 series_limit 时每个结果最多展示的序列数
+const defaultSeriesLimit = 20
+
+// formatValue 格式化数值，与图表刻度使用同样的格式
+func formatValue(v float64) string {
+	return chart.FormatValue(v)
+}
+
+// formatStats 格式化范围查询的统计值
+func formatStats(stats SeriesStats) string {
+	return fmt.Sprintf("min %s, max %s, avg %s, last %s, count %d",
+		formatValue(stats.Min), formatValue(stats.Max), formatValue(stats.Avg), formatValue(stats.Last), stats.Count)
+}
+
+// formatResult 将结果中的每条序列格式化为一行，超过 limit 的序列只给出数量
+func formatResult(result *Result, limit int) []string {
+	if limit <= 0 {
+		limit = defaultSeriesLimit
+	}
+
+	var lines []string
+	for i, series := range result.Series {
+		if i >= limit {
+			lines = append(lines, fmt.Sprintf("... %d more series", len(result.Series)-limit))
+			break
+		}
+		if len(series.Samples) == 0 {
+			continue
+		}
+		label := formatLabels(series.Labels)
+		switch result.Type {
+		case "matrix":
+			lines = append(lines, fmt.Sprintf("%s => %s", label, formatStats(computeStats(series))))
+		case "scalar", "string":
+			lines = append(lines, fmt.Sprintf("%s: %s", result.Type, formatSample(series.Samples[0])))
+		default:
+			lines = append(lines, fmt.Sprintf("%s => %s", label, formatSample(series.Samples[0])))
+		}
+	}
+	return lines
+}
+
+// summarizeResult 返回结果的简要说明，只有一条序列时直接给出值
+func summarizeResult(result *Result) string {
+	if len(result.Series) != 1 || len(result.Series[0].Samples) == 0 {
+		return fmt.Sprintf("%d series (%s)", len(result.Series), result.Type)
+	}
+
+	samples := result.Series[0].Samples
+	if result.Type == "matrix" {
+		return samples[len(samples)-1].Raw
+	}
+	return samples[0].Raw
+}
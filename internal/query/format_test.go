@@ -0,0 +1,31 @@
+package query
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{-42, "-42"},
+		{16e9, "16000000000"},
+		{17179869184, "17179869184"},
+		{0.5, "0.5"},
+		{1.23456789, "1.23457"},
+		{1234567.89, "1234568"},
+		{-8590551875.5, "-8590551876"},
+		{1e20, "1e+20"},
+		{math.NaN(), "NaN"},
+		{math.Inf(1), "+Inf"},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.v); got != tt.want {
+			t.Errorf("formatValue(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
@@ -25,6 +25,12 @@ func (m *Manager) registerCheckers() {
 	m.checkers["query_range"] = NewQueryRangeChecker(m.config)
 }
 
+// Checker 返回指定名称的查询检查器
+func (m *Manager) Checker(name string) (checker.Checker, bool) {
+	c, ok := m.checkers[name]
+	return c, ok
+}
+
 func (m *Manager) Check(queryType string) []checker.CheckResult {
 	if queryType == "all" {
 		return m.checkAll()
@@ -48,3 +54,19 @@ func (m *Manager) checkAll() []checker.CheckResult {
 	}
 	return results
 }
+
+// observer 由会记录原始结果的检查器实现
+type observer interface {
+	observed() []observation
+}
+
+// Compare 对比最近一次检查中同一查询在各节点上的结果
+func (m *Manager) Compare() ([]Comparison, []checker.CheckResult) {
+	var observations []observation
+	for _, name := range []string{"query", "query_range"} {
+		if o, ok := m.checkers[name].(observer); ok {
+			observations = append(observations, o.observed()...)
+		}
+	}
+	return compareObservations(globalConfig.Compare, observations)
+}
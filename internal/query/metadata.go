@@ -0,0 +1,303 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"ops_cli/internal/checker"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 元数据查询的种类
+const (
+	MetadataLabels  = "labels"
+	MetadataValues  = "values"
+	MetadataSeries  = "series"
+	MetadataMetrics = "metadata"
+)
+
+// 跨节点展示方式
+const (
+	ViewUnion = "union"
+	ViewDiff  = "diff"
+)
+
+// 节点上存在某个条目但没有可展示的值时使用的单元格内容
+const presentCell = "yes"
+
+// MetricMetadata 为 /api/v1/metadata 返回的一条指标元数据
+type MetricMetadata struct {
+	Type string `json:"type"`
+	Help string `json:"help"`
+	Unit string `json:"unit"`
+}
+
+// MetadataRequest 描述一次元数据查询，Matchers 和时间范围只对 labels、values、series 生效
+type MetadataRequest struct {
+	Kind string
+	// Label 为 values 查询的标签名
+	Label string
+	// Metric 为 metadata 查询的指标名，为空时返回全部指标
+	Metric   string
+	Matchers []string
+	// Start 和 End 为零时不限制时间范围
+	Start time.Time
+	End   time.Time
+}
+
+// Validate 检查查询种类和必需的参数
+func (r MetadataRequest) Validate() error {
+	switch r.Kind {
+	case MetadataLabels, MetadataMetrics:
+	case MetadataValues:
+		if !isIdentifier(r.Label) {
+			return fmt.Errorf("invalid label name %q", r.Label)
+		}
+	case MetadataSeries:
+		if len(r.Matchers) == 0 {
+			return fmt.Errorf("at least one --match selector is required for series")
+		}
+	default:
+		return fmt.Errorf("unknown metadata kind %q", r.Kind)
+	}
+	if !r.Start.IsZero() && !r.End.IsZero() && !r.End.After(r.Start) {
+		return fmt.Errorf("end %s must be after start %s", FormatTime(r.End), FormatTime(r.Start))
+	}
+	return nil
+}
+
+func (r MetadataRequest) params() url.Values {
+	params := url.Values{}
+	for _, matcher := range r.Matchers {
+		params.Add("match[]", matcher)
+	}
+	if !r.Start.IsZero() {
+		params.Set("start", strconv.FormatInt(r.Start.Unix(), 10))
+	}
+	if !r.End.IsZero() {
+		params.Set("end", strconv.FormatInt(r.End.Unix(), 10))
+	}
+	return params
+}
+
+// Labels 返回节点上的标签名
+func (c *APIClient) Labels(ip config.IPConfig, r MetadataRequest) ([]string, error) {
+	var labels []string
+	_, err := c.get(ip, config.PathLabels, r.params(), &labels)
+	return labels, err
+}
+
+// LabelValues 返回节点上某个标签的所有取值
+func (c *APIClient) LabelValues(ip config.IPConfig, r MetadataRequest) ([]string, error) {
+	baseUrl, err := config.GetUrl(ip.IP, ip.Role, config.ComponentPrometheus, config.PathLabel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get base url: %v", err)
+	}
+	// 完整路径为 /api/v1/label/<name>/values
+	var values []string
+	_, err = c.getURL(ip, baseUrl+"/"+url.PathEscape(r.Label)+"/values", r.params(), &values)
+	return values, err
+}
+
+// Series 返回节点上匹配选择器的序列标签
+func (c *APIClient) Series(ip config.IPConfig, r MetadataRequest) ([]map[string]string, error) {
+	var series []map[string]string
+	_, err := c.get(ip, config.PathSeries, r.params(), &series)
+	return series, err
+}
+
+// Metadata 返回节点上指标的类型、说明和单位，同一指标可能有多条不同的元数据
+func (c *APIClient) Metadata(ip config.IPConfig, r MetadataRequest) (map[string][]MetricMetadata, error) {
+	params := url.Values{}
+	if r.Metric != "" {
+		params.Set("metric", r.Metric)
+	}
+	var metadata map[string][]MetricMetadata
+	_, err := c.get(ip, config.PathMetadata, params, &metadata)
+	return metadata, err
+}
+
+// Inventory 汇总元数据条目在各节点上的情况，节点以 IP/角色 标识，Values 为 条目 -> 节点 -> 单元格内容，
+// 节点上不存在的条目不在 Values 中
+type Inventory struct {
+	Title  string
+	Hosts  []string
+	Items  []string
+	Values map[string]map[string]string
+	// Help 为 metadata 查询中各指标的说明，其他查询为空
+	Help map[string]string
+}
+
+// Consistent 判断条目是否在所有节点上都存在且内容相同
+func (inv Inventory) Consistent(item string) bool {
+	values := inv.Values[item]
+	if len(values) != len(inv.Hosts) {
+		return false
+	}
+	for _, host := range inv.Hosts {
+		if values[host] != values[inv.Hosts[0]] {
+			return false
+		}
+	}
+	return true
+}
+
+// Diff 返回只包含不一致条目的清单
+func (inv Inventory) Diff() Inventory {
+	diff := inv
+	diff.Items = nil
+	for _, item := range inv.Items {
+		if !inv.Consistent(item) {
+			diff.Items = append(diff.Items, item)
+		}
+	}
+	return diff
+}
+
+// FormatCell 返回条目在节点上的单元格内容，第二个返回值表示该单元格与多数节点不同
+func (inv Inventory) FormatCell(item, host string) (string, bool) {
+	values := inv.Values[item]
+	value, ok := values[host]
+	if !ok {
+		return "missing", true
+	}
+
+	counts := make(map[string]int)
+	for _, v := range values {
+		counts[v]++
+	}
+	for v, n := range counts {
+		if n > counts[value] || (n == counts[value] && v < value) {
+			return value, true
+		}
+	}
+	return value, false
+}
+
+// inventoryHost 返回清单中节点的列名，形如 192.168.20.133/fp
+func inventoryHost(ip config.IPConfig) string {
+	return ip.IP + "/" + ip.Role
+}
+
+// ExploreMetadata 在选中的节点上执行元数据查询，返回汇总清单和失败节点的结果
+func ExploreMetadata(r MetadataRequest, hosts []config.IPConfig) (Inventory, []checker.CheckResult) {
+	client := NewAPIClient()
+	inv := Inventory{
+		Title:  metadataTitle(r),
+		Values: make(map[string]map[string]string),
+	}
+	if r.Kind == MetadataMetrics {
+		inv.Help = make(map[string]string)
+	}
+
+	var failed []checker.CheckResult
+	for _, ip := range hosts {
+		items, err := fetchMetadata(client, ip, r, inv.Help)
+		if err != nil {
+			// 失败的节点不参与对比，避免所有条目都显示为缺失
+			failed = append(failed, checker.CheckResult{
+				Component: "metadata",
+				Item:      r.Kind,
+				Role:      ip.Role,
+				IP:        ip.IP,
+				Status:    "Failed",
+				Message:   fmt.Sprintf("Failed to fetch %s", r.Kind),
+				Error:     err,
+			})
+			log.Error("Failed to fetch %s from %s: %v", r.Kind, ip.IP, err)
+			continue
+		}
+
+		// 同一 IP 可能配置多个角色，按 IP 和角色区分节点
+		host := inventoryHost(ip)
+		inv.Hosts = append(inv.Hosts, host)
+		for item, value := range items {
+			if _, ok := inv.Values[item]; !ok {
+				inv.Values[item] = make(map[string]string)
+				inv.Items = append(inv.Items, item)
+			}
+			inv.Values[item][host] = value
+		}
+		log.Info("Fetched %d %s from %s", len(items), r.Kind, ip.IP)
+	}
+	sort.Strings(inv.Items)
+	return inv, failed
+}
+
+// fetchMetadata 返回节点上的条目及其单元格内容，metadata 查询同时记录指标说明
+func fetchMetadata(client *APIClient, ip config.IPConfig, r MetadataRequest, help map[string]string) (map[string]string, error) {
+	items := make(map[string]string)
+	switch r.Kind {
+	case MetadataLabels, MetadataValues:
+		fetch := client.Labels
+		if r.Kind == MetadataValues {
+			fetch = client.LabelValues
+		}
+		names, err := fetch(ip, r)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			items[name] = presentCell
+		}
+	case MetadataSeries:
+		series, err := client.Series(ip, r)
+		if err != nil {
+			return nil, err
+		}
+		for _, labels := range series {
+			items[formatLabels(labels)] = presentCell
+		}
+	case MetadataMetrics:
+		metadata, err := client.Metadata(ip, r)
+		if err != nil {
+			return nil, err
+		}
+		for metric, entries := range metadata {
+			items[metric] = formatMetadata(entries)
+			if _, ok := help[metric]; !ok && len(entries) > 0 {
+				help[metric] = entries[0].Help
+			}
+		}
+	}
+	return items, nil
+}
+
+// formatMetadata 将同一指标的多条元数据合并为 type 或 type (unit)，去重后以 / 分隔
+func formatMetadata(entries []MetricMetadata) string {
+	var parts []string
+	for _, entry := range entries {
+		part := entry.Type
+		if entry.Unit != "" {
+			part += " (" + entry.Unit + ")"
+		}
+		if !contains(parts, part) {
+			parts = append(parts, part)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "/")
+}
+
+func metadataTitle(r MetadataRequest) string {
+	title := "Labels"
+	switch r.Kind {
+	case MetadataValues:
+		title = fmt.Sprintf("Values of %s", r.Label)
+	case MetadataSeries:
+		title = "Series"
+	case MetadataMetrics:
+		title = "Metric metadata"
+		if r.Metric != "" {
+			title += " of " + r.Metric
+		}
+	}
+	if len(r.Matchers) > 0 {
+		title += " matching " + strings.Join(r.Matchers, ", ")
+	}
+	return title
+}
@@ -0,0 +1,50 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInventoryDiff(t *testing.T) {
+	inv := Inventory{
+		Hosts: []string{"a", "b", "c"},
+		Items: []string{"job", "mode", "up"},
+		Values: map[string]map[string]string{
+			"job":  {"a": "yes", "b": "yes", "c": "yes"},
+			"mode": {"a": "yes", "c": "yes"},
+			"up":   {"a": "gauge", "b": "counter", "c": "gauge"},
+		},
+	}
+
+	if got, want := inv.Diff().Items, []string{"mode", "up"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff().Items = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		item, host string
+		want       string
+		differs    bool
+	}{
+		{"job", "a", "yes", false},
+		{"mode", "b", "missing", true},
+		{"up", "a", "gauge", false},
+		{"up", "b", "counter", true},
+	}
+	for _, tt := range tests {
+		got, differs := inv.FormatCell(tt.item, tt.host)
+		if got != tt.want || differs != tt.differs {
+			t.Errorf("FormatCell(%q, %q) = %q, %v, want %q, %v", tt.item, tt.host, got, differs, tt.want, tt.differs)
+		}
+	}
+}
+
+func TestFormatMetadata(t *testing.T) {
+	entries := []MetricMetadata{
+		{Type: "gauge"},
+		{Type: "counter", Unit: "seconds"},
+		{Type: "gauge", Help: "other help"},
+	}
+	if got, want := formatMetadata(entries), "counter (seconds)/gauge"; got != want {
+		t.Errorf("formatMetadata() = %q, want %q", got, want)
+	}
+}
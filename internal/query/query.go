@@ -1,11 +1,7 @@
 package query
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"ops_cli/internal/checker"
 	"ops_cli/internal/config"
 	"ops_cli/pkg/log"
@@ -14,10 +10,11 @@ import (
 
 type QueryChecker struct {
 	config         *config.Config
-	client         *http.Client
+	client         *APIClient
 	generalQueries []PrometheusQuery
 	opsQueries     []PrometheusQuery
 	queryTime      string
+	observations   []observation
 }
 
 func NewQueryChecker(cfg *config.Config) *QueryChecker {
@@ -25,7 +22,7 @@ func NewQueryChecker(cfg *config.Config) *QueryChecker {
 	opsQueries, _, _ := loadQueries("query", "ops")
 	return &QueryChecker{
 		config:         cfg,
-		client:         &http.Client{Timeout: 60 * time.Second},
+		client:         NewAPIClient(),
 		generalQueries: generalQueries,
 		opsQueries:     opsQueries,
 		queryTime:      queryTime,
@@ -36,17 +33,32 @@ func (q *QueryChecker) Name() string {
 	return "query"
 }
 
+// observed 返回最近一次 Check 记录的原始结果，仅在开启 compare 时记录
+func (q *QueryChecker) observed() []observation {
+	return q.observations
+}
+
 func (q *QueryChecker) Check() []checker.CheckResult {
 	var results []checker.CheckResult
+	q.observations = nil
+
+	// 相对时间只解析一次，保证所有节点在同一时刻求值
+	queryTime, err := ParseTime(q.queryTime)
+	if err != nil {
+		for _, ip := range q.config.IPs {
+			results = append(results, q.createFailedResult("Query Time", ip, "Failed to parse query time", err))
+		}
+		return results
+	}
 
 	for _, ip := range q.config.IPs {
 		if ip.Role == "ops" {
 			for _, query := range q.opsQueries {
-				results = append(results, q.checkQuery(ip, query))
+				results = append(results, q.checkQuery(ip, query, queryTime))
 			}
 		} else {
 			for _, query := range q.generalQueries {
-				results = append(results, q.checkQuery(ip, query))
+				results = append(results, q.checkQuery(ip, query, queryTime))
 			}
 		}
 	}
@@ -54,66 +66,28 @@ func (q *QueryChecker) Check() []checker.CheckResult {
 	return results
 }
 
-func (q *QueryChecker) checkQuery(ip config.IPConfig, query PrometheusQuery) checker.CheckResult {
+func (q *QueryChecker) checkQuery(ip config.IPConfig, query PrometheusQuery, queryTime time.Time) checker.CheckResult {
 	log.Info("Checking Prometheus query for %s", ip.IP)
 
-	// Parse the queryTime into a time.Time object
-	parsedTime, err := time.ParseInLocation("2006-01-02 15:04:05", q.queryTime, time.FixedZone("CST", 8*3600))
+	expr, err := expandTemplate(query.Query, templateVars(ip, globalConfig.CLIVars))
 	if err != nil {
-		return q.createFailedResult(query.Name, ip, "Failed to parse query time", err)
+		return q.createFailedResult(query.Name, ip, "Invalid query template", err)
 	}
 
-	// Convert the time to Unix timestamp
-	unixTime := parsedTime.UnixNano() / int64(time.Second)
-
-	encodedQuery := url.QueryEscape(query.Query)
-	baseUrl, err := config.GetUrl(ip.IP, ip.Role, config.ComponentPrometheus, config.PathQuery)
+	res, err := q.client.Query(ip, expr, queryTime)
 	if err != nil {
-		return q.createFailedResult(query.Name, ip, "Failed to get base url", err)
+		return q.createFailedResult(query.Name, ip, queryErrorMessage(query.Name, err), err)
 	}
-	url := fmt.Sprintf("%s?query=%s&time=%d", baseUrl, encodedQuery, unixTime)
-	log.Info("Making HTTP request to %s with timeout %v", url, q.client.Timeout)
 
-	resp, err := q.client.Get(url)
 	result := q.createBaseResult(query.Name, ip)
+	applyAssertions(&result, query, res, globalConfig.SeriesLimit)
+	applyWarnings(&result, res.Warnings)
 
-	if err != nil {
-		return q.createFailedResult(query.Name, ip, "HTTP request failed", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return q.createFailedResult(query.Name, ip, fmt.Sprintf("API returned status code %d", resp.StatusCode), nil)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return q.createFailedResult(query.Name, ip, "Failed to read response body", err)
-	}
-
-	var jsonResponse struct {
-		Status string `json:"status"`
-		Data   struct {
-			ResultType string `json:"resultType"`
-			Result     []struct {
-				Value []interface{} `json:"value"`
-			} `json:"result"`
-		} `json:"data"`
-	}
-
-	if err := json.Unmarshal(body, &jsonResponse); err != nil {
-		return q.createFailedResult(query.Name, ip, "Failed to parse JSON response", err)
-	}
-
-	if len(jsonResponse.Data.Result) > 0 && len(jsonResponse.Data.Result[0].Value) > 1 {
-		result.Status = "Passed"
-		result.Message = fmt.Sprintf("%v", jsonResponse.Data.Result[0].Value[1])
-	} else {
-		result.Status = "Failed"
-		result.Message = "No data returned"
+	if globalConfig.Compare.Enabled {
+		q.observations = append(q.observations, observation{Component: q.Name(), IP: ip, Query: query, Result: res})
 	}
 
-	log.Info("Prometheus query check passed for %s", ip.IP)
+	log.Info("Prometheus query check completed for %s: %s", ip.IP, result.Status)
 
 	return result
 }
@@ -1,11 +1,7 @@
 package query
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"ops_cli/internal/checker"
 	"ops_cli/internal/config"
 	"ops_cli/pkg/log"
@@ -14,27 +10,28 @@ import (
 
 type QueryRangeChecker struct {
 	config         *config.Config
-	client         *http.Client
+	client         *APIClient
 	generalQueries []PrometheusQuery
 	opsQueries     []PrometheusQuery
-	start          time.Time
-	end            time.Time
+	start          string
+	end            string
+	step           time.Duration
+	charts         []Chart
+	observations   []observation
 }
 
 func NewQueryRangeChecker(cfg *config.Config) *QueryRangeChecker {
 	generalQueries, start, end := loadQueries("query_range", "general")
 	opsQueries, _, _ := loadQueries("query_range", "ops")
 
-	parsedStart, _ := time.ParseInLocation("2006-01-02 15:04:05", start, time.FixedZone("CST", 8*3600))
-	parsedEnd, _ := time.ParseInLocation("2006-01-02 15:04:05", end, time.FixedZone("CST", 8*3600))
-
 	return &QueryRangeChecker{
 		config:         cfg,
-		client:         &http.Client{Timeout: 60 * time.Second},
+		client:         NewAPIClient(),
 		generalQueries: generalQueries,
 		opsQueries:     opsQueries,
-		start:          parsedStart,
-		end:            parsedEnd,
+		start:          start,
+		end:            end,
+		step:           globalConfig.QueryRange.Step,
 	}
 }
 
@@ -42,8 +39,51 @@ func (qr *QueryRangeChecker) Name() string {
 	return "query_range"
 }
 
+// Charts 返回最近一次 Check 绘制的图表
+func (qr *QueryRangeChecker) Charts() []Chart {
+	return qr.charts
+}
+
+// observed 返回最近一次 Check 记录的原始结果，仅在开启 compare 时记录
+func (qr *QueryRangeChecker) observed() []observation {
+	return qr.observations
+}
+
 func (qr *QueryRangeChecker) Check() []checker.CheckResult {
 	var results []checker.CheckResult
+	qr.charts = nil
+	qr.observations = nil
+
+	// 相对时间只解析一次，保证所有节点查询同一时间窗口
+	start, err := ParseTime(qr.start)
+	if err == nil {
+		var end time.Time
+		if end, err = ParseTime(qr.end); err == nil {
+			return qr.checkAll(start, end)
+		}
+	}
+
+	for _, ip := range qr.config.IPs {
+		results = append(results, qr.createFailedResult("Time Range", ip, "Failed to parse query range", err))
+	}
+	return results
+}
+
+func (qr *QueryRangeChecker) checkAll(start, end time.Time) []checker.CheckResult {
+	var results []checker.CheckResult
+
+	if !end.After(start) {
+		for _, ip := range qr.config.IPs {
+			results = append(results, qr.createFailedResult("Time Range", ip,
+				fmt.Sprintf("End %s is not after start %s", end.In(timeLocation).Format(timeLayout), start.In(timeLocation).Format(timeLayout)), nil))
+		}
+		return results
+	}
+
+	step := ResolveStep(start, end, qr.step)
+	if qr.step > 0 && step != qr.step {
+		log.Warn("Step %s yields more than %d points, using %s", qr.step, maxRangePoints, step)
+	}
 
 	for _, ip := range qr.config.IPs {
 		log.Info("Checking Prometheus query range for %s", ip.IP)
@@ -52,74 +92,50 @@ func (qr *QueryRangeChecker) Check() []checker.CheckResult {
 			queries = qr.opsQueries
 		}
 		for _, query := range queries {
-			results = append(results, qr.checkQueryRange(ip, query))
+			results = append(results, qr.checkQueryRange(ip, query, start, end, step))
 		}
 	}
 
 	return results
 }
 
-func (qr *QueryRangeChecker) checkQueryRange(ip config.IPConfig, query PrometheusQuery) checker.CheckResult {
+func (qr *QueryRangeChecker) checkQueryRange(ip config.IPConfig, query PrometheusQuery, start, end time.Time, step time.Duration) checker.CheckResult {
 	log.Info("Checking Prometheus query range for %s", ip.IP)
 
-	unixStart := qr.start.Unix()
-	unixEnd := qr.end.Unix()
-
-	encodedQuery := url.QueryEscape(query.Query)
-	baseUrl, err := config.GetUrl(ip.IP, ip.Role, config.ComponentPrometheus, config.PathQueryRange)
+	expr, err := expandTemplate(query.Query, templateVars(ip, globalConfig.CLIVars))
 	if err != nil {
-		return qr.createFailedResult(query.Name, ip, "Failed to get base url", err)
+		return qr.createFailedResult(query.Name, ip, "Invalid query template", err)
 	}
-	url := fmt.Sprintf("%s?query=%s&start=%d&end=%d&step=60s", baseUrl, encodedQuery, unixStart, unixEnd)
-	log.Info("Making HTTP request to %s with timeout %v", url, qr.client.Timeout)
-
-	resp, err := qr.client.Get(url)
-	result := qr.createBaseResult(query.Name, ip)
 
+	res, err := qr.client.QueryRange(ip, expr, start, end, step)
 	if err != nil {
-		return qr.createFailedResult(query.Name, ip, "HTTP request failed", err)
+		return qr.createFailedResult(query.Name, ip, queryErrorMessage(query.Name, err), err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return qr.createFailedResult(query.Name, ip, fmt.Sprintf("API returned status code %d", resp.StatusCode), nil)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return qr.createFailedResult(query.Name, ip, "Failed to read response body", err)
-	}
+	result := qr.createBaseResult(query.Name, ip)
+	applyAssertions(&result, query, res, globalConfig.SeriesLimit)
+	applyWarnings(&result, res.Warnings)
 
-	var jsonResponse struct {
-		Status string `json:"status"`
-		Data   struct {
-			ResultType string `json:"resultType"`
-			Result     []struct {
-				Metric map[string]string `json:"metric"`
-				Values [][]interface{}   `json:"values"`
-			} `json:"result"`
-		} `json:"data"`
+	if globalConfig.Compare.Enabled {
+		qr.observations = append(qr.observations, observation{Component: qr.Name(), IP: ip, Query: query, Result: res})
 	}
 
-	if err := json.Unmarshal(body, &jsonResponse); err != nil {
-		return qr.createFailedResult(query.Name, ip, "Failed to parse JSON response", err)
+	if globalConfig.Chart.Enabled() && len(res.Series) > 0 {
+		title := fmt.Sprintf("%s - %s (%s)", query.Name, ip.IP, ip.Role)
+		qr.charts = append(qr.charts, renderChart(globalConfig.Chart, title, res, globalConfig.SeriesLimit))
 	}
 
-	if len(jsonResponse.Data.Result) > 0 && len(jsonResponse.Data.Result[0].Values) > 0 {
-		result.Status = "Passed"
-		lastValue := jsonResponse.Data.Result[0].Values[len(jsonResponse.Data.Result[0].Values)-1]
-		if len(lastValue) > 1 {
-			result.Message = fmt.Sprintf("%v", lastValue[1])
-		} else {
-			result.Status = "Failed"
-			result.Message = "Invalid value format in response"
+	if globalConfig.Export.Enabled() {
+		path, err := exportResult(globalConfig.Export, exportTarget{IP: ip, Name: query.Name, Query: expr}, res)
+		if err != nil {
+			return qr.createFailedResult(query.Name, ip, "Export failed", err)
+		}
+		if path != "" {
+			result.Details = append(result.Details, "exported to "+path)
 		}
-	} else {
-		result.Status = "Failed"
-		result.Message = "No data returned"
 	}
 
-	log.Info("Prometheus query range check completed for %s", ip.IP)
+	log.Info("Prometheus query range check completed for %s: %s", ip.IP, result.Status)
 
 	return result
 }
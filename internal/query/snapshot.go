@@ -0,0 +1,315 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"ops_cli/internal/checker"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 未配置 snapshot.dir 时快照的保存目录
+const defaultSnapshotDir = ".ops_cli/snapshots"
+
+// 快照名同时作为文件名，只允许字母、数字、点、下划线和连字符
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// SnapshotConfig 控制快照的保存位置和对比阈值，Percent 和 Absolute 都为 0 时按 10% 判断
+type SnapshotConfig struct {
+	Dir string `mapstructure:"dir"`
+	// Percent 为相对快照 a 的变化百分比阈值
+	Percent float64 `mapstructure:"percent"`
+	// Absolute 为变化绝对值阈值
+	Absolute float64 `mapstructure:"absolute"`
+	// IgnoreLabels 对齐序列时忽略的标签，如升级后会变化的 version
+	IgnoreLabels []string `mapstructure:"ignore_labels"`
+}
+
+// Path 返回快照文件路径
+func (c SnapshotConfig) Path(name string) (string, error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name %q, only letters, digits, '.', '_' and '-' are allowed", name)
+	}
+	dir := c.Dir
+	if dir == "" {
+		dir = defaultSnapshotDir
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// Snapshot 为某一时刻所有节点上所有配置查询的即时结果
+type Snapshot struct {
+	Name      string          `json:"name"`
+	Time      time.Time       `json:"time"`
+	CreatedAt time.Time       `json:"created_at"`
+	Entries   []SnapshotEntry `json:"entries"`
+}
+
+// SnapshotEntry 为一个查询在一个节点上的结果
+type SnapshotEntry struct {
+	Section string           `json:"section"`
+	Query   string           `json:"query"`
+	Expr    string           `json:"expr"`
+	IP      string           `json:"ip"`
+	Role    string           `json:"role"`
+	Error   string           `json:"error,omitempty"`
+	Series  []SnapshotSeries `json:"series"`
+}
+
+// SnapshotSeries 保存原始字符串形式的值，JSON 无法表示 NaN 和 Inf
+type SnapshotSeries struct {
+	Labels map[string]string `json:"labels"`
+	Value  string            `json:"value"`
+}
+
+func (e SnapshotEntry) key() string {
+	return fmt.Sprintf("%s/%s %s %s", e.Section, queryGroup(e.Role), e.Query, e.IP)
+}
+
+// TakeSnapshot 在 ts 时刻以即时查询执行 query 和 query_range 中配置的所有查询，
+// 返回快照和每个查询在每个节点上的执行结果
+func TakeSnapshot(name string, ts time.Time, hosts []config.IPConfig) (*Snapshot, []checker.CheckResult) {
+	client := NewAPIClient()
+	snapshot := &Snapshot{Name: name, Time: ts, CreatedAt: time.Now()}
+
+	var results []checker.CheckResult
+	for _, section := range []string{"query", "query_range"} {
+		for _, ip := range hosts {
+			queries, _, _ := loadQueries(section, queryGroup(ip.Role))
+			for _, query := range queries {
+				entry, result := snapshotQuery(client, section, ip, query, ts)
+				snapshot.Entries = append(snapshot.Entries, entry)
+				results = append(results, result)
+			}
+		}
+	}
+	return snapshot, results
+}
+
+func snapshotQuery(client *APIClient, section string, ip config.IPConfig, query PrometheusQuery, ts time.Time) (SnapshotEntry, checker.CheckResult) {
+	entry := SnapshotEntry{Section: section, Query: query.Name, IP: ip.IP, Role: ip.Role}
+	result := checker.CheckResult{
+		Component: "snapshot",
+		Item:      fmt.Sprintf("%s %s", section, query.Name),
+		Role:      ip.Role,
+		IP:        ip.IP,
+	}
+
+	expr, err := expandTemplate(query.Query, templateVars(ip, globalConfig.CLIVars))
+	if err == nil {
+		entry.Expr = expr
+		var res *Result
+		if res, err = client.Query(ip, expr, ts); err == nil {
+			for _, series := range res.Series {
+				if len(series.Samples) > 0 {
+					entry.Series = append(entry.Series, SnapshotSeries{Labels: series.Labels, Value: series.Samples[0].Raw})
+				}
+			}
+			result.Status = "Passed"
+			result.Message = fmt.Sprintf("%d series", len(entry.Series))
+			return entry, result
+		}
+	}
+
+	// 失败的查询也写入快照，对比时能区分查询失败和序列缺失
+	entry.Error = err.Error()
+	result.Status = "Failed"
+	result.Message = queryErrorMessage(query.Name, err)
+	result.Error = err
+	log.Error("Snapshot query %s failed for %s: %v", query.Name, ip.IP, err)
+	return entry, result
+}
+
+// CheckSnapshotPath 在 force 为 false 且快照已存在时返回错误，应在执行查询前调用
+func CheckSnapshotPath(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("snapshot %s already exists, use --force to overwrite", path)
+	}
+	return nil
+}
+
+// SaveSnapshot 将快照写入文件，force 为 false 时不覆盖已有快照
+func SaveSnapshot(path string, snapshot *Snapshot, force bool) error {
+	if err := CheckSnapshotPath(path, force); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	return nil
+}
+
+// LoadSnapshot 读取快照文件
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %v", path, err)
+	}
+	return &snapshot, nil
+}
+
+// seriesChange 表示一条序列在两个快照之间的变化
+type seriesChange struct {
+	labels            string
+	before, after     float64
+	inBefore, inAfter bool
+}
+
+// DiffSnapshots 按查询和节点对比两个快照，变化超过阈值、缺失或新增序列的结果为 Warning，
+// 只在一个快照中存在或查询失败的结果为 Failed
+func DiffSnapshots(cfg SnapshotConfig, a, b *Snapshot) []checker.CheckResult {
+	threshold := CompareConfig{Percent: cfg.Percent, Absolute: cfg.Absolute}
+	if threshold.Percent <= 0 && threshold.Absolute <= 0 {
+		threshold.Percent = defaultComparePercent
+	}
+
+	after := make(map[string]SnapshotEntry)
+	for _, entry := range b.Entries {
+		after[entry.key()] = entry
+	}
+
+	var results []checker.CheckResult
+	seen := make(map[string]bool)
+	for _, before := range a.Entries {
+		seen[before.key()] = true
+		result := checker.CheckResult{
+			Component: "snapshot",
+			Item:      fmt.Sprintf("%s %s", before.Section, before.Query),
+			Role:      before.Role,
+			IP:        before.IP,
+		}
+
+		entry, ok := after[before.key()]
+		switch {
+		case !ok:
+			result.Status = "Failed"
+			result.Message = fmt.Sprintf("Not in snapshot %s", b.Name)
+		case before.Error != "" || entry.Error != "":
+			result.Status = "Failed"
+			result.Message = "Query failed in a snapshot"
+			for _, e := range []struct{ name, err string }{{a.Name, before.Error}, {b.Name, entry.Error}} {
+				if e.err != "" {
+					result.Details = append(result.Details, fmt.Sprintf("%s: %s", e.name, e.err))
+				}
+			}
+		default:
+			diffEntry(&result, threshold, cfg.IgnoreLabels, before, entry)
+		}
+		results = append(results, result)
+	}
+
+	for _, entry := range b.Entries {
+		if seen[entry.key()] {
+			continue
+		}
+		results = append(results, checker.CheckResult{
+			Component: "snapshot",
+			Item:      fmt.Sprintf("%s %s", entry.Section, entry.Query),
+			Role:      entry.Role,
+			IP:        entry.IP,
+			Status:    "Failed",
+			Message:   fmt.Sprintf("Not in snapshot %s", a.Name),
+		})
+	}
+	return results
+}
+
+// diffEntry 对比同一查询在两个快照中的序列，将变化写入结果。
+// 值在数字和 NaN 之间变化或展开后的查询不同时也按变化处理
+func diffEntry(result *checker.CheckResult, threshold CompareConfig, ignore []string, before, after SnapshotEntry) {
+	changes := alignSnapshotSeries(before.Series, after.Series, ignore)
+
+	exprChanged := before.Expr != after.Expr
+	if exprChanged {
+		result.Details = append(result.Details, fmt.Sprintf("! query %s", before.Expr), fmt.Sprintf("! query %s", after.Expr))
+	}
+
+	var changed, missing, added int
+	for _, c := range changes {
+		switch {
+		case c.inBefore && !c.inAfter:
+			missing++
+			result.Details = append(result.Details, fmt.Sprintf("- %s %s (missing)", c.labels, formatValue(c.before)))
+		case c.inAfter && !c.inBefore:
+			added++
+			result.Details = append(result.Details, fmt.Sprintf("+ %s %s (new)", c.labels, formatValue(c.after)))
+		case math.IsNaN(c.before) != math.IsNaN(c.after):
+			changed++
+			result.Details = append(result.Details, fmt.Sprintf("~ %s %s -> %s",
+				c.labels, formatValue(c.before), formatValue(c.after)))
+		case isOutlier(threshold, c.after, c.before):
+			changed++
+			result.Details = append(result.Details, fmt.Sprintf("~ %s %s -> %s (%s)",
+				c.labels, formatValue(c.before), formatValue(c.after), formatDeviation(c.after, c.before)))
+		}
+	}
+
+	if changed+missing+added == 0 && !exprChanged {
+		result.Status = "Passed"
+		result.Message = fmt.Sprintf("%d series unchanged", len(changes))
+		return
+	}
+	result.Status = "Warning"
+	result.Message = fmt.Sprintf("%d changed, %d missing, %d new of %d series", changed, missing, added, len(changes))
+	if exprChanged {
+		result.Message = "Query changed, " + result.Message
+	}
+}
+
+// alignSnapshotSeries 按去掉忽略标签后的标签集合对齐两组序列，相同标签集合的值求和
+func alignSnapshotSeries(before, after []SnapshotSeries, ignore []string) []seriesChange {
+	values := make(map[string]*seriesChange)
+	var keys []string
+	add := func(series []SnapshotSeries, isAfter bool) {
+		for _, s := range series {
+			labels := formatLabels(withoutLabels(s.Labels, ignore))
+			c, ok := values[labels]
+			if !ok {
+				c = &seriesChange{labels: labels}
+				values[labels] = c
+				keys = append(keys, labels)
+			}
+			// 无法解析的值按 NaN 处理，不参与阈值判断
+			v, err := strconv.ParseFloat(s.Value, 64)
+			if err != nil {
+				v = math.NaN()
+			}
+			if isAfter {
+				c.after += v
+				c.inAfter = true
+			} else {
+				c.before += v
+				c.inBefore = true
+			}
+		}
+	}
+	add(before, false)
+	add(after, true)
+
+	sort.Strings(keys)
+	changes := make([]seriesChange, 0, len(keys))
+	for _, k := range keys {
+		changes = append(changes, *values[k])
+	}
+	return changes
+}
@@ -0,0 +1,61 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	entry := func(ip string, series ...SnapshotSeries) SnapshotEntry {
+		return SnapshotEntry{Section: "query", Query: "up", IP: ip, Role: "fp", Series: series}
+	}
+	up := func(instance, value string) SnapshotSeries {
+		return SnapshotSeries{Labels: map[string]string{"__name__": "up", "instance": instance}, Value: value}
+	}
+
+	changedExpr := entry("10.0.0.5", up("a", "1"))
+	changedExpr.Expr = `up{job="node"}`
+
+	a := &Snapshot{Name: "before", Entries: []SnapshotEntry{
+		entry("10.0.0.1", up("a", "100"), up("b", "1"), up("c", "NaN")),
+		entry("10.0.0.2", up("a", "1")),
+		entry("10.0.0.3", up("a", "1")),
+		entry("10.0.0.4", up("a", "1"), up("b", "NaN")),
+		entry("10.0.0.5", up("a", "1")),
+	}}
+	b := &Snapshot{Name: "after", Entries: []SnapshotEntry{
+		entry("10.0.0.1", up("a", "105"), up("c", "NaN"), up("d", "1")),
+		entry("10.0.0.2", up("a", "2")),
+		entry("10.0.0.4", up("a", "NaN"), up("b", "1")),
+		changedExpr,
+	}}
+
+	results := DiffSnapshots(SnapshotConfig{}, a, b)
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want 5", len(results))
+	}
+
+	want := []struct {
+		status  string
+		message string
+		details int
+	}{
+		// a 变化 5% 未超过默认的 10%，c 两侧都是 NaN 不算变化
+		{"Warning", "0 changed, 1 missing, 1 new of 4 series", 2},
+		{"Warning", "1 changed, 0 missing, 0 new of 1 series", 1},
+		{"Failed", "Not in snapshot after", 0},
+		// 数字和 NaN 之间的变化
+		{"Warning", "2 changed, 0 missing, 0 new of 2 series", 2},
+		{"Warning", "Query changed, 0 changed, 0 missing, 0 new of 1 series", 2},
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Status != w.status || r.Message != w.message || len(r.Details) != w.details {
+			t.Errorf("result %d = %s %q %v, want %s %q with %d details", i, r.Status, r.Message, r.Details, w.status, w.message, w.details)
+		}
+	}
+
+	results = DiffSnapshots(SnapshotConfig{Absolute: 2}, a, b)
+	if results[0].Message != "1 changed, 1 missing, 1 new of 4 series" {
+		t.Errorf("with absolute threshold got %q", results[0].Message)
+	}
+}
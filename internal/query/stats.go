@@ -0,0 +1,41 @@
+package query
+
+import (
+	"math"
+)
+
+// SeriesStats 表示一条序列在查询窗口内的汇总统计
+type SeriesStats struct {
+	Min   float64
+	Max   float64
+	Avg   float64
+	Last  float64
+	Count int
+}
+
+// computeStats 计算序列的统计值，NaN 样本不参与计算
+func computeStats(series Series) SeriesStats {
+	stats := SeriesStats{Min: math.Inf(1), Max: math.Inf(-1)}
+
+	sum := 0.0
+	for _, sample := range series.Samples {
+		if math.IsNaN(sample.Value) {
+			continue
+		}
+		stats.Count++
+		sum += sample.Value
+		stats.Last = sample.Value
+		if sample.Value < stats.Min {
+			stats.Min = sample.Value
+		}
+		if sample.Value > stats.Max {
+			stats.Max = sample.Value
+		}
+	}
+
+	if stats.Count == 0 {
+		return SeriesStats{Min: math.NaN(), Max: math.NaN(), Avg: math.NaN(), Last: math.NaN()}
+	}
+	stats.Avg = sum / float64(stats.Count)
+	return stats
+}
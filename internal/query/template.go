@@ -0,0 +1,191 @@
+package query
+
+import (
+	"fmt"
+	"ops_cli/internal/config"
+	"regexp"
+	"strings"
+)
+
+// 字符串字面量之外的变量值只能是指标名、标签名、数字或时长，防止改变查询结构
+var bareValuePattern = regexp.MustCompile(`^[A-Za-z0-9_.:]+$`)
+
+// templateVars 返回节点上可用的模板变量，优先级从低到高依次为
+// 内置的 ip 和 role、query.yaml 中的 vars、节点的 vars、命令行 --var。
+// viper 会把 map 的键转为小写，变量名因此不区分大小写
+func templateVars(ip config.IPConfig, cliVars map[string]string) map[string]string {
+	vars := map[string]string{
+		"ip":   ip.IP,
+		"role": ip.Role,
+	}
+	for _, layer := range []map[string]string{globalConfig.Vars, ip.Vars, cliVars} {
+		for k, v := range layer {
+			vars[strings.ToLower(k)] = v
+		}
+	}
+	return vars
+}
+
+// expandTemplate 展开查询中的 $name、${name} 和 ${name:modifier} 变量，$$ 表示字面量 $。
+// 变量位于字符串字面量中时按 PromQL 字符串规则转义；modifier 为 regex 时先转义正则元字符，
+// 为 raw 时原样插入。字符串之外的变量值只允许指标名、数字或时长等简单记号
+func expandTemplate(tmpl string, vars map[string]string) (string, error) {
+	var b strings.Builder
+	var quote rune // 当前所在字符串的引号，0 表示不在字符串中
+
+	runes := []rune(tmpl)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if quote != 0 {
+			switch {
+			case r == '\\' && quote != '`' && i+1 < len(runes):
+				b.WriteRune(r)
+				b.WriteRune(runes[i+1])
+				i++
+				continue
+			case r == quote:
+				quote = 0
+			}
+		} else if r == '"' || r == '\'' || r == '`' {
+			quote = r
+		}
+
+		if r != '$' {
+			b.WriteRune(r)
+			continue
+		}
+
+		name, modifier, end, err := parseVariable(runes, i)
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			// $$ 或 $ 后不是变量名，如正则中的 $
+			b.WriteRune('$')
+			i = end - 1
+			continue
+		}
+
+		value, ok := vars[strings.ToLower(name)]
+		if !ok {
+			return "", fmt.Errorf("undefined variable %q at position %d", name, i+1)
+		}
+		escaped, err := escapeValue(name, value, modifier, quote)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(escaped)
+		i = end - 1
+	}
+
+	if quote != 0 {
+		return "", fmt.Errorf("unterminated string literal starting with %c", quote)
+	}
+	return b.String(), nil
+}
+
+// parseVariable 解析从 runes[start]（即 $）开始的变量，返回变量名、修饰符和结束位置。
+// 变量名为空时表示字面量 $，end 大于 start+1 时跳过了 $$ 中的第二个 $
+func parseVariable(runes []rune, start int) (name, modifier string, end int, err error) {
+	i := start + 1
+	if i >= len(runes) {
+		return "", "", i, nil
+	}
+
+	switch {
+	case runes[i] == '$':
+		return "", "", i + 1, nil
+	case runes[i] == '{':
+		closing := -1
+		for j := i + 1; j < len(runes); j++ {
+			if runes[j] == '}' {
+				closing = j
+				break
+			}
+			if !isIdentPart(runes[j]) && runes[j] != ':' {
+				break
+			}
+		}
+		if closing < 0 {
+			return "", "", 0, fmt.Errorf("unterminated ${ at position %d", start+1)
+		}
+		body := string(runes[i+1 : closing])
+		name, modifier, _ = strings.Cut(body, ":")
+		if !isIdentifier(name) {
+			return "", "", 0, fmt.Errorf("invalid variable name %q at position %d", name, start+1)
+		}
+		switch modifier {
+		case "", "regex", "raw":
+		default:
+			return "", "", 0, fmt.Errorf("unknown modifier %q for variable %q, expected regex or raw", modifier, name)
+		}
+		return name, modifier, closing + 1, nil
+	case isIdentStart(runes[i]):
+		j := i
+		for j < len(runes) && isIdentPart(runes[j]) {
+			j++
+		}
+		return string(runes[i:j]), "", j, nil
+	}
+	return "", "", i, nil
+}
+
+// escapeValue 按变量所在位置转义变量值
+func escapeValue(name, value, modifier string, quote rune) (string, error) {
+	if modifier == "raw" {
+		return value, nil
+	}
+	if modifier == "regex" {
+		value = regexp.QuoteMeta(value)
+	}
+
+	switch quote {
+	case '"', '\'':
+		replacer := strings.NewReplacer(`\`, `\\`, string(quote), `\`+string(quote), "\n", `\n`)
+		return replacer.Replace(value), nil
+	case '`':
+		if strings.ContainsRune(value, '`') {
+			return "", fmt.Errorf("value of variable %q contains a backtick and cannot be used in a raw string", name)
+		}
+		return value, nil
+	}
+
+	if !bareValuePattern.MatchString(value) {
+		return "", fmt.Errorf("value %q of variable %q must be quoted, outside a string only names, numbers and durations are allowed (use ${%s:raw} to insert it verbatim)", value, name, name)
+	}
+	return value, nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || !isIdentStart(rune(s[0])) {
+		return false
+	}
+	for _, r := range s {
+		if !isIdentPart(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || (r >= '0' && r <= '9')
+}
+
+// ParseVars 解析命令行传入的 key=value 变量
+func ParseVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || !isIdentifier(key) {
+			return nil, fmt.Errorf("invalid variable %q, expected key=value", pair)
+		}
+		vars[strings.ToLower(key)] = value
+	}
+	return vars, nil
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string]string{
+		"ip":    "10.0.0.1",
+		"role":  "fp",
+		"job":   `node"x`,
+		"hosts": "a.b|c",
+		"range": "5m",
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`up{instance="$ip:9100"}`, `up{instance="10.0.0.1:9100"}`},
+		{`up{instance='${ip}:9100', role="$ROLE"}`, `up{instance='10.0.0.1:9100', role="fp"}`},
+		{`up{job="$job"}`, `up{job="node\"x"}`},
+		{`up{instance=~"${ip:regex}:.*"}`, `up{instance=~"10\\.0\\.0\\.1:.*"}`},
+		{`up{instance=~"${hosts:raw}"}`, `up{instance=~"a.b|c"}`},
+		{`rate(node_cpu_seconds_total[$range])`, `rate(node_cpu_seconds_total[5m])`},
+		{`up{job=~"api$|web$"}`, `up{job=~"api$|web$"}`},
+		{`up{job="cost$$"}`, `up{job="cost$"}`},
+		{"up{job=`$hosts`}", "up{job=`a.b|c`}"},
+	}
+
+	for _, tt := range tests {
+		got, err := expandTemplate(tt.in, vars)
+		if err != nil {
+			t.Errorf("expandTemplate(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandTemplate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpandTemplateErrors(t *testing.T) {
+	vars := map[string]string{"ip": "10.0.0.1", "job": `a"} or vector(1)`}
+
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{`up{instance="$missing"}`, `undefined variable "missing"`},
+		{`up{instance="${ip"}`, "unterminated ${"},
+		{`up{instance="${ip:upper}"}`, `unknown modifier "upper"`},
+		{`up{instance="$ip}`, "unterminated string literal"},
+		{`up{job=$job}`, "must be quoted"},
+	}
+
+	for _, tt := range tests {
+		_, err := expandTemplate(tt.in, vars)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("expandTemplate(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+		}
+	}
+}
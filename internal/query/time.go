@@ -0,0 +1,124 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	// 内置时区数据，目标机器缺少 /usr/share/zoneinfo 时也能加载时区
+	_ "time/tzdata"
+)
+
+// 查询配置中绝对时间字符串的格式
+const timeLayout = "2006-01-02 15:04:05"
+
+// Prometheus 单个范围查询最多返回的点数
+const maxRangePoints = 11000
+
+// 自动计算步长时期望的点数
+const autoStepPoints = 250
+
+// timeLocation 为解析绝对时间和展示结果使用的时区，默认 CST +8
+var timeLocation = time.FixedZone("CST", 8*3600)
+
+// SetTimezone 设置解析和展示时间使用的时区，支持 IANA 名称、Local 和 UTC
+func SetTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %v", name, err)
+	}
+	timeLocation = loc
+	return nil
+}
+
+// ParseTime 解析查询时间，空字符串表示当前时间
+func ParseTime(s string) (time.Time, error) {
+	return parseTimeAt(s, time.Now(), timeLocation)
+}
+
+// parseTimeAt 支持以下格式：
+//   - now、now-1h、now+30m，时长单位额外支持 d 和 w
+//   - RFC3339，如 2024-12-18T23:23:00+08:00
+//   - unix 时间戳（秒），可带小数
+//   - 2006-01-02 15:04:05，按 loc 解析
+func parseTimeAt(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "now" {
+		return now, nil
+	}
+
+	if strings.HasPrefix(s, "now") {
+		rest := strings.TrimSpace(s[len("now"):])
+		if len(rest) < 2 || (rest[0] != '-' && rest[0] != '+') {
+			return time.Time{}, fmt.Errorf("invalid relative time %q, expected now-<duration> or now+<duration>", s)
+		}
+		d, err := parseDuration(strings.TrimSpace(rest[1:]))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative time %q: %v", s, err)
+		}
+		if rest[0] == '-' {
+			d = -d
+		}
+		return now.Add(d), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	if ts, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(ts)
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	}
+
+	t, err := time.ParseInLocation(timeLayout, s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected now[-+]<duration>, RFC3339, unix timestamp or %q", s, timeLayout)
+	}
+	return t, nil
+}
+
+// parseDuration 在 time.ParseDuration 基础上支持 d（天）和 w（周）
+func parseDuration(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if v, err := strconv.ParseFloat(n, 64); err == nil {
+				return time.Duration(v * float64(unit)), nil
+			}
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// ResolveStep 返回范围查询的步长：step 为 0 时按窗口自动计算，
+// 并保证点数不超过 Prometheus 的 11000 点限制
+func ResolveStep(start, end time.Time, step time.Duration) time.Duration {
+	window := end.Sub(start)
+	if window <= 0 {
+		if step > 0 {
+			return step
+		}
+		return time.Second
+	}
+
+	if step <= 0 {
+		step = (window / autoStepPoints).Round(time.Second)
+	}
+
+	minStep := time.Duration(math.Ceil(float64(window) / maxRangePoints))
+	if step < minStep {
+		step = minStep.Truncate(time.Second)
+		if step < minStep {
+			step += time.Second
+		}
+	}
+
+	if step < time.Second {
+		step = time.Second
+	}
+	return step
+}
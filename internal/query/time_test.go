@@ -0,0 +1,66 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeAt(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	now := time.Date(2024, 12, 18, 15, 23, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", now},
+		{"now", now},
+		{"now-1h", now.Add(-time.Hour)},
+		{"now+30m", now.Add(30 * time.Minute)},
+		{"now-2d", now.Add(-48 * time.Hour)},
+		{"now-1w", now.Add(-7 * 24 * time.Hour)},
+		{"2024-12-18T23:23:00+08:00", now},
+		{"1734535380", now},
+		{"1734535380.5", now.Add(500 * time.Millisecond)},
+		{"2024-12-18 23:23:00", now},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimeAt(tt.in, now, loc)
+		if err != nil {
+			t.Errorf("parseTimeAt(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeAt(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"now-", "now*1h", "now-1x", "yesterday", "2024-12-18"} {
+		if _, err := parseTimeAt(in, now, loc); err == nil {
+			t.Errorf("parseTimeAt(%q) expected error", in)
+		}
+	}
+}
+
+func TestResolveStep(t *testing.T) {
+	start := time.Date(2024, 12, 18, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		window time.Duration
+		step   time.Duration
+		want   time.Duration
+	}{
+		{time.Minute, 0, time.Second},
+		{time.Hour, 0, 14 * time.Second},
+		{time.Hour, 30 * time.Second, 30 * time.Second},
+		{30 * 24 * time.Hour, time.Second, 236 * time.Second},
+		{0, 0, time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := ResolveStep(start, start.Add(tt.window), tt.step); got != tt.want {
+			t.Errorf("ResolveStep(%s, %s) = %s, want %s", tt.window, tt.step, got, tt.want)
+		}
+	}
+}
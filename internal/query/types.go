@@ -1,12 +1,25 @@
 package query
 
 import (
+	"errors"
 	"github.com/spf13/viper"
+	"time"
 )
 
 type PrometheusQuery struct {
 	Name  string `mapstructure:"name"`
 	Query string `mapstructure:"query"`
+	// 以下为可选断言，即时查询逐条序列比较，范围查询先按 aggregate 汇总窗口
+	Warning  *float64 `mapstructure:"warning"`
+	Critical *float64 `mapstructure:"critical"`
+	// Operator 为超限条件 value <op> threshold，默认 >
+	Operator string `mapstructure:"operator"`
+	// Aggregate 为范围查询的汇总方式：avg（默认）、min、max、last
+	Aggregate string `mapstructure:"aggregate"`
+	// Expect 为 non_empty（默认）、empty 或 any
+	Expect string `mapstructure:"expect"`
+	// Series 为期望的序列数
+	Series *int `mapstructure:"series"`
 }
 
 type QueryConfig struct {
@@ -15,16 +28,34 @@ type QueryConfig struct {
 }
 
 type Config struct {
-	Query struct {
+	// SeriesLimit 每个查询结果最多展示的序列数
+	SeriesLimit int `mapstructure:"series_limit"`
+	// Timezone 解析绝对时间和展示结果使用的时区，为空时使用 CST +8
+	Timezone string `mapstructure:"timezone"`
+	// Export 导出范围查询的完整结果
+	Export ExportConfig `mapstructure:"export"`
+	// Chart 在终端中绘制范围查询结果
+	Chart ChartConfig `mapstructure:"chart"`
+	// Compare 按标签集合对比同一查询在各节点上的结果
+	Compare CompareConfig `mapstructure:"compare"`
+	// Snapshot 保存和对比查询快照
+	Snapshot SnapshotConfig `mapstructure:"snapshot"`
+	// Vars 为所有节点共用的模板变量
+	Vars map[string]string `mapstructure:"vars"`
+	// CLIVars 为命令行 --var 传入的模板变量，优先级最高
+	CLIVars map[string]string `mapstructure:"-"`
+	Query   struct {
 		QueryTime string      `mapstructure:"query_time"`
 		Ops       QueryConfig `mapstructure:"ops"`
 		General   QueryConfig `mapstructure:"general"`
 	} `mapstructure:"query"`
 	QueryRange struct {
-		Start   string      `mapstructure:"start"`
-		End     string      `mapstructure:"end"`
-		Ops     QueryConfig `mapstructure:"ops"`
-		General QueryConfig `mapstructure:"general"`
+		Start string `mapstructure:"start"`
+		End   string `mapstructure:"end"`
+		// Step 为 0 时按时间窗口自动计算
+		Step    time.Duration `mapstructure:"step"`
+		Ops     QueryConfig   `mapstructure:"ops"`
+		General QueryConfig   `mapstructure:"general"`
 	} `mapstructure:"query_range"`
 }
 
@@ -46,7 +77,21 @@ func LoadConfig(queryConfig string) error {
 		return err
 	}
 
-	return v.Unmarshal(&globalConfig)
+	if err := v.Unmarshal(&globalConfig); err != nil {
+		return err
+	}
+	return SetTimezone(globalConfig.Timezone)
+}
+
+// LoadOptionalConfig 与 LoadConfig 相同，但未指定路径且当前目录没有 query.yaml 时不报错，
+// 供 -e 等不依赖配置中查询的命令使用
+func LoadOptionalConfig(queryConfig string) error {
+	err := LoadConfig(queryConfig)
+	var notFound viper.ConfigFileNotFoundError
+	if queryConfig == "" && errors.As(err, &notFound) {
+		return nil
+	}
+	return err
 }
 
 func GetConfig() *Config {
@@ -0,0 +1,172 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"ops_cli/internal/checker"
+	"ops_cli/internal/config"
+	"ops_cli/pkg/promql"
+	"strings"
+)
+
+// checkPromQL 在本地解析查询，范围查询的结果必须是瞬时向量或标量
+func checkPromQL(expr string, isRange bool) (promql.ValueType, error) {
+	typ, err := promql.Check(expr)
+	if err != nil {
+		return "", err
+	}
+	if isRange && typ != promql.ValueVector && typ != promql.ValueScalar {
+		return typ, fmt.Errorf("range query needs an instant vector or scalar expression, got %s", typ)
+	}
+	return typ, nil
+}
+
+// errorContext 返回 PromQL 解析错误所在的行及指向出错位置的标记
+func errorContext(err error) []string {
+	var perr *promql.Error
+	if errors.As(err, &perr) {
+		return perr.Context()
+	}
+	return nil
+}
+
+// queryIssue 记录一个查询在若干节点上的同一个错误
+type queryIssue struct {
+	status  string
+	message string
+	details []string
+	hosts   []string
+}
+
+// ValidateQueries 在本地展开并解析各节点将要执行的查询，不发送任何请求。
+// 查询合法时每个分组的每个查询返回一条 Passed，否则同一错误按节点合并为一条 Failed，
+// 本地函数表中没有的函数只返回 Warning，由服务端判断。断言配置有误时直接返回一条 Failed
+func ValidateQueries(ips []config.IPConfig, queryType string) []checker.CheckResult {
+	var results []checker.CheckResult
+	forEachQuery(ips, queryType, func(section, group string, query PrometheusQuery, hosts []config.IPConfig) {
+		if result, ok := validateAssertion(section, group, query); !ok {
+			results = append(results, result)
+			return
+		}
+		results = append(results, validateQuery(section, group, query, hosts)...)
+	})
+	return results
+}
+
+// ValidateAssertions 只检查各查询的断言配置，断言与节点和服务端无关，跳过 PromQL 检查时也需要执行
+func ValidateAssertions(ips []config.IPConfig, queryType string) []checker.CheckResult {
+	var results []checker.CheckResult
+	forEachQuery(ips, queryType, func(section, group string, query PrometheusQuery, hosts []config.IPConfig) {
+		if result, ok := validateAssertion(section, group, query); !ok {
+			results = append(results, result)
+		}
+	})
+	return results
+}
+
+// RangeTargets 返回范围查询将产生的结果数，即每个查询乘以其分组中的节点数
+func RangeTargets(ips []config.IPConfig) int {
+	targets := 0
+	forEachQuery(ips, "query_range", func(section, group string, query PrometheusQuery, hosts []config.IPConfig) {
+		targets += len(hosts)
+	})
+	return targets
+}
+
+// forEachQuery 遍历将要执行的查询及其所在分组的节点
+func forEachQuery(ips []config.IPConfig, queryType string, fn func(section, group string, query PrometheusQuery, hosts []config.IPConfig)) {
+	for _, section := range []string{"query", "query_range"} {
+		if queryType != "all" && queryType != section {
+			continue
+		}
+		for _, group := range []string{"ops", "general"} {
+			var hosts []config.IPConfig
+			for _, ip := range ips {
+				if queryGroup(ip.Role) == group {
+					hosts = append(hosts, ip)
+				}
+			}
+			// 没有节点的分组不会执行，模板中的节点变量也无从展开
+			if len(hosts) == 0 {
+				continue
+			}
+
+			queries, _, _ := loadQueries(section, group)
+			for _, query := range queries {
+				fn(section, group, query, hosts)
+			}
+		}
+	}
+}
+
+// validateAssertion 检查一个查询的断言配置，不合法时返回 Failed 结果和 false
+func validateAssertion(section, group string, query PrometheusQuery) (checker.CheckResult, bool) {
+	if err := query.validate(); err != nil {
+		return checker.CheckResult{
+			Component: section,
+			Item:      query.Name,
+			Role:      group,
+			Status:    "Failed",
+			Message:   fmt.Sprintf("Invalid assertion in query %q: %v", query.Name, err),
+		}, false
+	}
+	return checker.CheckResult{}, true
+}
+
+func validateQuery(section, group string, query PrometheusQuery, hosts []config.IPConfig) []checker.CheckResult {
+	var issues []*queryIssue
+	byMessage := make(map[string]*queryIssue)
+	var typ promql.ValueType
+
+	for _, ip := range hosts {
+		status := "Failed"
+		var message string
+		var details []string
+		expr, err := expandTemplate(query.Query, templateVars(ip, globalConfig.CLIVars))
+		if err != nil {
+			message = fmt.Sprintf("Invalid query template: %v", err)
+		} else if t, err := checkPromQL(expr, section == "query_range"); promql.IsUnknownFunction(err) {
+			status = "Warning"
+			message = fmt.Sprintf("Not checked locally in query %q: %v", query.Name, err)
+			details = errorContext(err)
+		} else if err != nil {
+			message = fmt.Sprintf("Invalid PromQL in query %q: %v", query.Name, err)
+			details = errorContext(err)
+		} else {
+			typ = t
+			continue
+		}
+
+		issue, ok := byMessage[message]
+		if !ok {
+			issue = &queryIssue{status: status, message: message, details: details}
+			byMessage[message] = issue
+			issues = append(issues, issue)
+		}
+		issue.hosts = append(issue.hosts, ip.IP)
+	}
+
+	if len(issues) == 0 {
+		return []checker.CheckResult{{
+			Component: section,
+			Item:      query.Name,
+			Role:      group,
+			Status:    "Passed",
+			Message:   fmt.Sprintf("Valid, returns %s", typ),
+		}}
+	}
+
+	var results []checker.CheckResult
+	for _, issue := range issues {
+		results = append(results, checker.CheckResult{
+			Component: section,
+			Item:      query.Name,
+			Role:      group,
+			IP:        strings.Join(issue.hosts, ","),
+			Status:    issue.status,
+			Message:   issue.message,
+			Details:   issue.details,
+		})
+	}
+	return results
+}
@@ -0,0 +1,226 @@
+// Package chart 在终端中以 ASCII 折线图或 Unicode 火花线绘制时间序列
+package chart
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Series 表示一条待绘制的序列，X 和 Y 一一对应
+type Series struct {
+	Label string
+	X     []float64
+	Y     []float64
+}
+
+// 折线图中各序列使用的标记，超过数量后循环使用
+var markers = []rune{'*', '+', 'o', 'x', '#', '@', '%', '&'}
+
+// 火花线使用的八级方块
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Line 将所有序列画在同一坐标系中，返回包含坐标轴和图例的多行文本。
+// width 为整体宽度，height 为绘图区行数，formatX 用于格式化横轴刻度
+func Line(series []Series, width, height int, formatX func(float64) string) []string {
+	if height < 2 {
+		height = 2
+	}
+	xmin, xmax, ymin, ymax, ok := bounds(series)
+	if !ok {
+		return []string{"(no data)"}
+	}
+
+	yLabels := []string{FormatValue(ymax), FormatValue((ymin + ymax) / 2), FormatValue(ymin)}
+	labelWidth := 0
+	for _, label := range yLabels {
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+	plotWidth := width - labelWidth - 2
+	if plotWidth < 10 {
+		plotWidth = 10
+	}
+
+	grid := make([][]rune, height)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", plotWidth))
+	}
+	for i, s := range series {
+		marker := markers[i%len(markers)]
+		for col, v := range resample(s.X, s.Y, xmin, xmax, plotWidth) {
+			if math.IsNaN(v) {
+				continue
+			}
+			grid[height-1-scale(v, ymin, ymax, height)][col] = marker
+		}
+	}
+
+	var lines []string
+	for row := range grid {
+		label := ""
+		switch row {
+		case 0:
+			label = yLabels[0]
+		case (height - 1) / 2:
+			label = yLabels[1]
+		case height - 1:
+			label = yLabels[2]
+		}
+		lines = append(lines, fmt.Sprintf("%*s |%s", labelWidth, label, string(grid[row])))
+	}
+	lines = append(lines, fmt.Sprintf("%*s +%s", labelWidth, "", strings.Repeat("-", plotWidth)))
+	lines = append(lines, strings.Repeat(" ", labelWidth+2)+xAxis(formatX(xmin), formatX((xmin+xmax)/2), formatX(xmax), plotWidth))
+
+	for i, s := range series {
+		lines = append(lines, fmt.Sprintf("  %c %s  %s", markers[i%len(markers)], s.Label, summary(s.Y)))
+	}
+	return lines
+}
+
+// Sparkline 为每条序列输出标签行和一行火花线，火花线后附带 min/max/last
+func Sparkline(series []Series, width int) []string {
+	xmin, xmax, _, _, ok := bounds(series)
+	if !ok {
+		return []string{"(no data)"}
+	}
+
+	var lines []string
+	for _, s := range series {
+		stats := summary(s.Y)
+		sparkWidth := width - 4 - utf8.RuneCountInString(stats)
+		if sparkWidth < 10 {
+			sparkWidth = 10
+		}
+		// 点数少于宽度时每个点占一格，避免出现空隙
+		if points := len(s.Y); points > 0 && points < sparkWidth {
+			sparkWidth = points
+		}
+
+		// 每条序列按自身范围缩放，便于观察趋势
+		_, _, ymin, ymax, _ := bounds([]Series{s})
+		var b strings.Builder
+		for _, v := range resample(s.X, s.Y, xmin, xmax, sparkWidth) {
+			if math.IsNaN(v) {
+				b.WriteRune(' ')
+				continue
+			}
+			b.WriteRune(sparkBlocks[scale(v, ymin, ymax, len(sparkBlocks))])
+		}
+		lines = append(lines, s.Label, fmt.Sprintf("  %s  %s", b.String(), stats))
+	}
+	return lines
+}
+
+// bounds 返回所有序列中非 NaN 点的坐标范围
+func bounds(series []Series) (xmin, xmax, ymin, ymax float64, ok bool) {
+	xmin, ymin = math.Inf(1), math.Inf(1)
+	xmax, ymax = math.Inf(-1), math.Inf(-1)
+	for _, s := range series {
+		for i, y := range s.Y {
+			if math.IsNaN(y) || math.IsInf(y, 0) {
+				continue
+			}
+			ok = true
+			xmin, xmax = math.Min(xmin, s.X[i]), math.Max(xmax, s.X[i])
+			ymin, ymax = math.Min(ymin, y), math.Max(ymax, y)
+		}
+	}
+	return
+}
+
+// resample 将点按横轴分到 n 个桶中取平均，空桶为 NaN
+func resample(xs, ys []float64, xmin, xmax float64, n int) []float64 {
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	for i, y := range ys {
+		if math.IsNaN(y) || math.IsInf(y, 0) {
+			continue
+		}
+		col := 0
+		if xmax > xmin {
+			col = int((xs[i] - xmin) / (xmax - xmin) * float64(n))
+		}
+		if col >= n {
+			col = n - 1
+		}
+		sums[col] += y
+		counts[col]++
+	}
+
+	out := make([]float64, n)
+	for i := range out {
+		if counts[i] == 0 {
+			out[i] = math.NaN()
+		} else {
+			out[i] = sums[i] / float64(counts[i])
+		}
+	}
+	return out
+}
+
+// scale 将 v 映射到 [0, levels) 区间，范围为零时取中间
+func scale(v, lo, hi float64, levels int) int {
+	if hi <= lo {
+		return levels / 2
+	}
+	level := int(math.Round((v - lo) / (hi - lo) * float64(levels-1)))
+	if level < 0 {
+		return 0
+	}
+	if level >= levels {
+		return levels - 1
+	}
+	return level
+}
+
+// xAxis 在 width 宽度内放置左、中、右三个刻度，放不下时省略中间刻度
+func xAxis(left, middle, right string, width int) string {
+	line := []rune(strings.Repeat(" ", width))
+	place := func(s string, at int) {
+		for i, r := range []rune(s) {
+			if at+i >= 0 && at+i < width {
+				line[at+i] = r
+			}
+		}
+	}
+
+	place(left, 0)
+	place(right, width-utf8.RuneCountInString(right))
+	mid := width/2 - utf8.RuneCountInString(middle)/2
+	if mid > utf8.RuneCountInString(left)+1 && mid+utf8.RuneCountInString(middle) < width-utf8.RuneCountInString(right)-1 {
+		place(middle, mid)
+	}
+	return strings.TrimRight(string(line), " ")
+}
+
+func summary(ys []float64) string {
+	lo, hi, last := math.Inf(1), math.Inf(-1), math.NaN()
+	for _, y := range ys {
+		if math.IsNaN(y) {
+			continue
+		}
+		lo, hi, last = math.Min(lo, y), math.Max(hi, y), y
+	}
+	if math.IsNaN(last) {
+		return "no data"
+	}
+	return fmt.Sprintf("min %s  max %s  last %s", FormatValue(lo), FormatValue(hi), FormatValue(last))
+}
+
+// FormatValue 格式化数值，整数原样输出不带小数位和指数，如内存和字节数，超过 6 位整数的值舍去小数，
+// 其他值保留 6 位有效数字。查询结果表格使用同样的格式，保证图表刻度与表格一致
+func FormatValue(v float64) string {
+	switch {
+	case math.Abs(v) >= 1e18 || math.IsNaN(v):
+		return strconv.FormatFloat(v, 'g', 6, 64)
+	case v == math.Trunc(v):
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case math.Abs(v) >= 1e6:
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
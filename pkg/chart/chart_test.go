@@ -0,0 +1,66 @@
+package chart
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLineFitsWidth(t *testing.T) {
+	series := []Series{
+		{Label: "a", X: []float64{0, 1, 2, 3}, Y: []float64{1, 2, 3, 4}},
+		{Label: "b", X: []float64{0, 1, 2, 3}, Y: []float64{4, 3, 2, 1}},
+	}
+	lines := Line(series, 40, 5, func(x float64) string { return "t" })
+
+	// 5 行绘图区 + 横轴 + 刻度 + 2 行图例
+	if len(lines) != 9 {
+		t.Fatalf("Expected 9 lines, got %d:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	for _, line := range lines[:6] {
+		if n := utf8.RuneCountInString(line); n != 40 {
+			t.Errorf("Expected width 40, got %d: %q", n, line)
+		}
+	}
+	if !strings.HasPrefix(lines[0], "  4 |+") || !strings.HasSuffix(lines[0], "*") {
+		t.Errorf("Expected max label and a point on the top row, got %q", lines[0])
+	}
+	if !strings.Contains(lines[7], "min 1  max 4  last 4") {
+		t.Errorf("Unexpected legend %q", lines[7])
+	}
+}
+
+// 大计数器的刻度与查询结果表格一致，不使用指数
+func TestLineLargeValues(t *testing.T) {
+	series := []Series{{Label: "bytes", X: []float64{0, 1}, Y: []float64{1234567, 17179869184}}}
+	lines := Line(series, 60, 4, func(x float64) string { return "t" })
+
+	if !strings.HasPrefix(lines[0], "17179869184 |") {
+		t.Errorf("Expected an integral max label, got %q", lines[0])
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "e+") {
+			t.Errorf("Unexpected exponent in %q", line)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	series := []Series{{Label: "up", X: []float64{0, 1, 2, 3, 4, 5, 6, 7}, Y: []float64{0, 1, 2, 3, 4, 5, 6, 7}}}
+	lines := Sparkline(series, 45)
+
+	if len(lines) != 2 || lines[0] != "up" {
+		t.Fatalf("Unexpected sparkline output %q", lines)
+	}
+	spark := strings.Fields(lines[1])[0]
+	if !strings.HasPrefix(spark, "▁") || !strings.HasSuffix(spark, "█") {
+		t.Errorf("Expected sparkline to rise from ▁ to █, got %q", spark)
+	}
+}
+
+func TestResampleAveragesBuckets(t *testing.T) {
+	got := resample([]float64{0, 1, 2, 3}, []float64{1, 3, 5, 7}, 0, 3, 2)
+	if got[0] != 2 || got[1] != 6 {
+		t.Errorf("Unexpected buckets %v", got)
+	}
+}
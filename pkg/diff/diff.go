@@ -0,0 +1,168 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type opType int
+
+const (
+	opEqual opType = iota
+	opDelete
+	opInsert
+)
+
+// edit 表示编辑脚本中的一步，aPos/bPos 为执行该步之前已消耗的行数
+type edit struct {
+	op   opType
+	line string
+	aPos int
+	bPos int
+}
+
+// Unified 生成 a 到 b 的统一格式差异，内容相同时返回 nil
+func Unified(aName, bName string, a, b []string, context int) []string {
+	edits := myers(a, b)
+
+	var changes []int
+	for i, e := range edits {
+		if e.op != opEqual {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	lines := []string{"--- " + aName, "+++ " + bName}
+
+	// 将相距不超过 2*context 的改动合并到同一个 hunk
+	for i := 0; i < len(changes); {
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context {
+			j++
+		}
+		end := changes[j] + context
+		if end > len(edits)-1 {
+			end = len(edits) - 1
+		}
+
+		lines = append(lines, hunk(edits[start:end+1])...)
+		i = j + 1
+	}
+
+	return lines
+}
+
+// Lines 将文本拆分为行，忽略末尾换行
+func Lines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func hunk(edits []edit) []string {
+	aLen, bLen := 0, 0
+	var body []string
+	for _, e := range edits {
+		switch e.op {
+		case opEqual:
+			aLen++
+			bLen++
+			body = append(body, " "+e.line)
+		case opDelete:
+			aLen++
+			body = append(body, "-"+e.line)
+		case opInsert:
+			bLen++
+			body = append(body, "+"+e.line)
+		}
+	}
+
+	aStart, bStart := edits[0].aPos, edits[0].bPos
+	if aLen > 0 {
+		aStart++
+	}
+	if bLen > 0 {
+		bStart++
+	}
+
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", aStart, aLen, bStart, bLen)
+	return append([]string{header}, body...)
+}
+
+// myers 使用 Myers 算法计算最短编辑脚本
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	var trace [][]int
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// 从终点回溯得到逆序的编辑脚本
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{op: opEqual, line: a[x-1], aPos: x - 1, bPos: y - 1})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{op: opInsert, line: b[y-1], aPos: x, bPos: y - 1})
+				y--
+			} else {
+				edits = append(edits, edit{op: opDelete, line: a[x-1], aPos: x - 1, bPos: y})
+				x--
+			}
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
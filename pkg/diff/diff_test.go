@@ -0,0 +1,44 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	a := Lines("global:\n  scrape_interval: 15s\n  evaluation_interval: 15s\nrule_files:\n  - rules/*.yml\n")
+	b := Lines("global:\n  scrape_interval: 30s\n  evaluation_interval: 15s\nrule_files:\n  - rules/*.yml\n  - extra/*.yml\n")
+
+	got := strings.Join(Unified("a", "b", a, b, 1), "\n")
+	want := strings.Join([]string{
+		"--- a",
+		"+++ b",
+		"@@ -1,3 +1,3 @@",
+		" global:",
+		"-  scrape_interval: 15s",
+		"+  scrape_interval: 30s",
+		"   evaluation_interval: 15s",
+		"@@ -5,1 +5,2 @@",
+		"   - rules/*.yml",
+		"+  - extra/*.yml",
+	}, "\n")
+
+	if got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedIdentical(t *testing.T) {
+	lines := Lines("a\nb\nc\n")
+	if d := Unified("a", "b", lines, lines, 3); d != nil {
+		t.Errorf("Expected no diff for identical input, got %v", d)
+	}
+}
+
+func TestUnifiedEmpty(t *testing.T) {
+	got := strings.Join(Unified("a", "b", nil, Lines("x\n"), 3), "\n")
+	want := "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+x"
+	if got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
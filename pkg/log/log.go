@@ -55,6 +55,7 @@ type Logger struct {
 	verbose   bool
 	mu        sync.Mutex
 	writers   []io.Writer
+	console   io.Writer
 	startTime time.Time
 	useColor  bool
 }
@@ -71,6 +72,7 @@ func InitLogger() {
 			logger:    log.New(os.Stdout, "", 0),
 			level:     LevelInfo,
 			writers:   []io.Writer{os.Stdout},
+			console:   os.Stdout,
 			startTime: time.Now(),
 			useColor:  true, // 默认启用颜色
 		}
@@ -105,7 +107,7 @@ func SetOutput(file string) error {
 	defer defaultLogger.mu.Unlock()
 
 	// 重置writers
-	defaultLogger.writers = []io.Writer{os.Stdout}
+	defaultLogger.writers = []io.Writer{defaultLogger.console}
 
 	if file != "" {
 		// 确保目录存在
@@ -130,6 +132,20 @@ func SetOutput(file string) error {
 	return nil
 }
 
+// SetConsole 设置终端输出，命令需要在标准输出写数据时将日志切换到标准错误
+func SetConsole(w io.Writer) {
+	if defaultLogger == nil {
+		return
+	}
+
+	defaultLogger.mu.Lock()
+	defer defaultLogger.mu.Unlock()
+
+	defaultLogger.console = w
+	defaultLogger.writers[0] = w
+	defaultLogger.logger.SetOutput(io.MultiWriter(defaultLogger.writers...))
+}
+
 func (l *Logger) getCallerInfo() string {
 	_, file, line, ok := runtime.Caller(3) // 跳过更多的调用层级以获取实际调用者
 	if !ok {
@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sample 表示文本格式中的一行样本
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Family 表示同名指标的集合
+type Family struct {
+	Name    string
+	Type    string
+	Help    string
+	Samples []Sample
+}
+
+// 直方图和摘要的样本后缀，用于归并到所属的 Family
+var familySuffixes = []string{"_bucket", "_sum", "_count", "_total", "_created"}
+
+// Parse 解析 Prometheus 文本暴露格式，返回以 Family 名为键的集合
+func Parse(r io.Reader) (map[string]*Family, error) {
+	families := make(map[string]*Family)
+
+	getFamily := func(name string) *Family {
+		f, ok := families[name]
+		if !ok {
+			f = &Family{Name: name, Type: "untyped"}
+			families[name] = f
+		}
+		return f
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "TYPE":
+				if len(fields) >= 4 {
+					getFamily(fields[2]).Type = fields[3]
+				}
+			case "HELP":
+				help := strings.TrimSpace(strings.TrimPrefix(line[1:], " HELP "+fields[2]))
+				getFamily(fields[2]).Help = help
+			}
+			continue
+		}
+
+		sample, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+
+		f := getFamily(familyName(families, sample.Name))
+		f.Samples = append(f.Samples, sample)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return families, nil
+}
+
+// Names 返回排好序的 Family 名称
+func Names(families map[string]*Family) []string {
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// familyName 查找样本所属的已声明 Family，找不到时使用样本名本身
+func familyName(families map[string]*Family, sampleName string) string {
+	if _, ok := families[sampleName]; ok {
+		return sampleName
+	}
+	for _, suffix := range familySuffixes {
+		if base := strings.TrimSuffix(sampleName, suffix); base != sampleName {
+			if _, ok := families[base]; ok {
+				return base
+			}
+		}
+	}
+	return sampleName
+}
+
+func parseSample(line string) (Sample, error) {
+	sample := Sample{Labels: make(map[string]string)}
+
+	rest := line
+	if i := strings.IndexAny(rest, "{ \t"); i >= 0 {
+		sample.Name = rest[:i]
+		rest = rest[i:]
+	} else {
+		return sample, fmt.Errorf("missing value in %q", line)
+	}
+
+	if strings.HasPrefix(rest, "{") {
+		end, err := parseLabels(rest, sample.Labels)
+		if err != nil {
+			return sample, err
+		}
+		rest = rest[end:]
+	}
+
+	// 值后面可能还跟着时间戳
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return sample, fmt.Errorf("missing value in %q", line)
+	}
+
+	value, err := parseValue(fields[0])
+	if err != nil {
+		return sample, fmt.Errorf("invalid value %q: %v", fields[0], err)
+	}
+	sample.Value = value
+
+	return sample, nil
+}
+
+// parseLabels 解析 {k="v",...}，返回右花括号之后的位置
+func parseLabels(s string, labels map[string]string) (int, error) {
+	i := 1
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return 0, fmt.Errorf("unterminated label set")
+		}
+		if s[i] == '}' {
+			return i + 1, nil
+		}
+
+		eq := strings.IndexByte(s[i:], '=')
+		if eq < 0 {
+			return 0, fmt.Errorf("invalid label set")
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+
+		if i >= len(s) || s[i] != '"' {
+			return 0, fmt.Errorf("label %s value must be quoted", name)
+		}
+		i++
+
+		var value strings.Builder
+		for {
+			if i >= len(s) {
+				return 0, fmt.Errorf("unterminated value for label %s", name)
+			}
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				switch s[i+1] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i+1])
+				}
+				i += 2
+				continue
+			}
+			if c == '"' {
+				i++
+				break
+			}
+			value.WriteByte(c)
+			i++
+		}
+		labels[name] = value.String()
+	}
+}
+
+func parseValue(s string) (float64, error) {
+	switch s {
+	case "+Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
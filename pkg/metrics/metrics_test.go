@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+const exposition = `# HELP node_exporter_build_info A metric with a constant '1' value.
+# TYPE node_exporter_build_info gauge
+node_exporter_build_info{branch="HEAD",goversion="go1.20.4",revision="abc",version="1.6.1"} 1
+# HELP http_request_duration_seconds Request latency.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{le="0.1"} 3
+http_request_duration_seconds_bucket{le="+Inf"} 5
+http_request_duration_seconds_sum 1.5
+http_request_duration_seconds_count 5
+node_load1 0.42 1700000000000
+label_escape{path="C:\\dir",msg="say \"hi\""} 1
+`
+
+func TestParse(t *testing.T) {
+	families, err := Parse(strings.NewReader(exposition))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	if len(families) != 4 {
+		t.Fatalf("Expected 4 families, got %d: %v", len(families), Names(families))
+	}
+
+	build := families["node_exporter_build_info"]
+	if build == nil || build.Type != "gauge" || build.Samples[0].Labels["version"] != "1.6.1" {
+		t.Errorf("Unexpected build info family: %+v", build)
+	}
+
+	hist := families["http_request_duration_seconds"]
+	if hist == nil || len(hist.Samples) != 4 {
+		t.Errorf("Expected histogram samples to be grouped, got %+v", hist)
+	}
+
+	if load := families["node_load1"]; load == nil || load.Samples[0].Value != 0.42 {
+		t.Errorf("Unexpected untyped family: %+v", load)
+	}
+
+	escaped := families["label_escape"].Samples[0].Labels
+	if escaped["path"] != `C:\dir` || escaped["msg"] != `say "hi"` {
+		t.Errorf("Unexpected escaped labels: %v", escaped)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse(strings.NewReader("metric{a=\"b\" 1\n")); err == nil {
+		t.Error("Expected error for unterminated label set")
+	}
+	if _, err := Parse(strings.NewReader("metric abc\n")); err == nil {
+		t.Error("Expected error for invalid value")
+	}
+}
@@ -1,11 +1,15 @@
 package output
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/olekukonko/tablewriter"
+	"golang.org/x/term"
 	"io"
 	"ops_cli/internal/checker"
 	"os"
+	"strconv"
+	"strings"
 )
 
 // 抽取公共的表格配置函数
@@ -13,18 +17,7 @@ func configureTable(table *tablewriter.Table, withColor bool) {
 	// 设置表头
 	table.SetHeader([]string{"Component", "Role", "IP", "Item", "Status", "Message"})
 
-	// 设置表格样式
-	table.SetAutoWrapText(false)
-	table.SetAutoFormatHeaders(true)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetCenterSeparator("")
-	table.SetColumnSeparator("")
-	table.SetRowSeparator("-")
-	table.SetHeaderLine(true)
-	table.SetBorder(false)
-	table.SetTablePadding("\t")
-	table.SetNoWhiteSpace(true)
+	setTableStyle(table)
 
 	if withColor {
 		// 设置表头颜色
@@ -49,6 +42,21 @@ func configureTable(table *tablewriter.Table, withColor bool) {
 	}
 }
 
+// setTableStyle 设置所有表格共用的样式
+func setTableStyle(table *tablewriter.Table) {
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("-")
+	table.SetHeaderLine(true)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+}
+
 // 抽取公共的添加数据行函数
 func addTableRows(table *tablewriter.Table, results []checker.CheckResult, withColor bool) {
 	for _, result := range results {
@@ -59,6 +67,9 @@ func addTableRows(table *tablewriter.Table, results []checker.CheckResult, withC
 			}
 			message += result.Error.Error()
 		}
+		if len(result.Details) > 0 {
+			message += "\n" + strings.Join(result.Details, "\n")
+		}
 
 		row := []string{
 			result.Component,
@@ -69,13 +80,25 @@ func addTableRows(table *tablewriter.Table, results []checker.CheckResult, withC
 			message,
 		}
 
-		if withColor && result.Status == "Failed" {
+		if !withColor {
+			table.Append(row)
+			continue
+		}
+
+		switch result.Status {
+		case "Failed":
 			table.Rich(row, []tablewriter.Colors{
 				{}, {}, {}, {},
 				{tablewriter.FgRedColor},
 				{},
 			})
-		} else {
+		case "Warning":
+			table.Rich(row, []tablewriter.Colors{
+				{}, {}, {}, {},
+				{tablewriter.FgYellowColor},
+				{},
+			})
+		default:
 			table.Append(row)
 		}
 	}
@@ -87,7 +110,7 @@ func renderTable(w io.Writer, results []checker.CheckResult, withColor bool) {
 	configureTable(table, withColor)
 	addTableRows(table, results, withColor)
 
-	fmt.Fprintln(w, "\nCheck Results:\n")
+	fmt.Fprint(w, "\nCheck Results:\n\n")
 	table.Render()
 	fmt.Fprintln(w)
 }
@@ -106,3 +129,77 @@ func FormatCheckResultsToFile(results []checker.CheckResult, filename string) er
 	renderTable(file, results, false)
 	return nil
 }
+
+// FormatTable 以与检查结果相同的样式渲染任意表格
+func FormatTable(title string, headers []string, rows [][]string) {
+	renderPlainTable(os.Stdout, title, headers, rows)
+}
+
+func renderPlainTable(w io.Writer, title string, headers []string, rows [][]string) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(headers)
+	setTableStyle(table)
+	table.AppendBulk(rows)
+
+	fmt.Fprintf(w, "\n%s:\n\n", title)
+	table.Render()
+	fmt.Fprintln(w)
+}
+
+// FormatJSON 以缩进的 JSON 输出任意数据
+func FormatJSON(v interface{}) error {
+	return writeJSON(os.Stdout, v)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// FormatMatrix 渲染行列矩阵，failed 中为 true 的单元格以红色高亮
+func FormatMatrix(title string, corner string, rows []string, cols []string, cells [][]string, failed [][]bool) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(append([]string{corner}, cols...))
+	setTableStyle(table)
+	// 表头为节点 IP 等原始值，不做格式化
+	table.SetAutoFormatHeaders(false)
+
+	for i, row := range rows {
+		colors := []tablewriter.Colors{{tablewriter.Bold}}
+		for j := range cols {
+			if failed[i][j] {
+				colors = append(colors, tablewriter.Colors{tablewriter.FgRedColor})
+			} else {
+				colors = append(colors, tablewriter.Colors{})
+			}
+		}
+		table.Rich(append([]string{row}, cells[i]...), colors)
+	}
+
+	fmt.Fprintf(os.Stdout, "\n%s:\n\n", title)
+	table.Render()
+	fmt.Fprintln(os.Stdout)
+}
+
+// 无法获取终端宽度时使用的默认宽度
+const defaultTerminalWidth = 100
+
+// TerminalWidth 返回标准输出所在终端的宽度，非终端时读取 COLUMNS 环境变量
+func TerminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultTerminalWidth
+}
+
+// FormatChart 输出带标题的终端图表
+func FormatChart(title string, lines []string) {
+	fmt.Fprintf(os.Stdout, "\n%s:\n\n", title)
+	for _, line := range lines {
+		fmt.Fprintln(os.Stdout, line)
+	}
+}
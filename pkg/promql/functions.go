@@ -0,0 +1,128 @@
+package promql
+
+// function 描述函数的参数类型和返回类型。MinArgs 小于参数个数时末尾参数可省略，
+// Variadic 为 true 时最后一个参数可以重复任意次
+type function struct {
+	Args       []ValueType
+	MinArgs    int
+	Variadic   bool
+	ReturnType ValueType
+}
+
+func fn(ret ValueType, args ...ValueType) function {
+	return function{Args: args, MinArgs: len(args), ReturnType: ret}
+}
+
+// optional 返回末尾 n 个参数可省略的函数
+func optional(n int, ret ValueType, args ...ValueType) function {
+	f := fn(ret, args...)
+	f.MinArgs -= n
+	return f
+}
+
+// variadic 返回最后一个参数可以重复的函数，至少需要 min 个参数
+func variadic(min int, ret ValueType, args ...ValueType) function {
+	f := fn(ret, args...)
+	f.MinArgs = min
+	f.Variadic = true
+	return f
+}
+
+// functions 为 Prometheus 支持的函数
+var functions = map[string]function{
+	"abs":                          fn(ValueVector, ValueVector),
+	"absent":                       fn(ValueVector, ValueVector),
+	"absent_over_time":             fn(ValueVector, ValueMatrix),
+	"acos":                         fn(ValueVector, ValueVector),
+	"acosh":                        fn(ValueVector, ValueVector),
+	"asin":                         fn(ValueVector, ValueVector),
+	"asinh":                        fn(ValueVector, ValueVector),
+	"atan":                         fn(ValueVector, ValueVector),
+	"atanh":                        fn(ValueVector, ValueVector),
+	"avg_over_time":                fn(ValueVector, ValueMatrix),
+	"ceil":                         fn(ValueVector, ValueVector),
+	"changes":                      fn(ValueVector, ValueMatrix),
+	"clamp":                        fn(ValueVector, ValueVector, ValueScalar, ValueScalar),
+	"clamp_max":                    fn(ValueVector, ValueVector, ValueScalar),
+	"clamp_min":                    fn(ValueVector, ValueVector, ValueScalar),
+	"cos":                          fn(ValueVector, ValueVector),
+	"cosh":                         fn(ValueVector, ValueVector),
+	"count_over_time":              fn(ValueVector, ValueMatrix),
+	"day_of_month":                 optional(1, ValueVector, ValueVector),
+	"day_of_week":                  optional(1, ValueVector, ValueVector),
+	"day_of_year":                  optional(1, ValueVector, ValueVector),
+	"days_in_month":                optional(1, ValueVector, ValueVector),
+	"deg":                          fn(ValueVector, ValueVector),
+	"delta":                        fn(ValueVector, ValueMatrix),
+	"deriv":                        fn(ValueVector, ValueMatrix),
+	"double_exponential_smoothing": fn(ValueVector, ValueMatrix, ValueScalar, ValueScalar),
+	"exp":                          fn(ValueVector, ValueVector),
+	"floor":                        fn(ValueVector, ValueVector),
+	"histogram_avg":                fn(ValueVector, ValueVector),
+	"histogram_count":              fn(ValueVector, ValueVector),
+	"histogram_fraction":           fn(ValueVector, ValueScalar, ValueScalar, ValueVector),
+	"histogram_quantile":           fn(ValueVector, ValueScalar, ValueVector),
+	"histogram_stddev":             fn(ValueVector, ValueVector),
+	"histogram_stdvar":             fn(ValueVector, ValueVector),
+	"histogram_sum":                fn(ValueVector, ValueVector),
+	"holt_winters":                 fn(ValueVector, ValueMatrix, ValueScalar, ValueScalar),
+	"hour":                         optional(1, ValueVector, ValueVector),
+	"idelta":                       fn(ValueVector, ValueMatrix),
+	"increase":                     fn(ValueVector, ValueMatrix),
+	"irate":                        fn(ValueVector, ValueMatrix),
+	"label_join":                   variadic(3, ValueVector, ValueVector, ValueString, ValueString, ValueString),
+	"label_replace":                fn(ValueVector, ValueVector, ValueString, ValueString, ValueString, ValueString),
+	"last_over_time":               fn(ValueVector, ValueMatrix),
+	"ln":                           fn(ValueVector, ValueVector),
+	"log10":                        fn(ValueVector, ValueVector),
+	"log2":                         fn(ValueVector, ValueVector),
+	"mad_over_time":                fn(ValueVector, ValueMatrix),
+	"max_over_time":                fn(ValueVector, ValueMatrix),
+	"min_over_time":                fn(ValueVector, ValueMatrix),
+	"minute":                       optional(1, ValueVector, ValueVector),
+	"month":                        optional(1, ValueVector, ValueVector),
+	"pi":                           fn(ValueScalar),
+	"predict_linear":               fn(ValueVector, ValueMatrix, ValueScalar),
+	"present_over_time":            fn(ValueVector, ValueMatrix),
+	"quantile_over_time":           fn(ValueVector, ValueScalar, ValueMatrix),
+	"rad":                          fn(ValueVector, ValueVector),
+	"rate":                         fn(ValueVector, ValueMatrix),
+	"resets":                       fn(ValueVector, ValueMatrix),
+	"round":                        optional(1, ValueVector, ValueVector, ValueScalar),
+	"scalar":                       fn(ValueScalar, ValueVector),
+	"sgn":                          fn(ValueVector, ValueVector),
+	"sin":                          fn(ValueVector, ValueVector),
+	"sinh":                         fn(ValueVector, ValueVector),
+	"sort":                         fn(ValueVector, ValueVector),
+	"sort_by_label":                variadic(1, ValueVector, ValueVector, ValueString),
+	"sort_by_label_desc":           variadic(1, ValueVector, ValueVector, ValueString),
+	"sort_desc":                    fn(ValueVector, ValueVector),
+	"sqrt":                         fn(ValueVector, ValueVector),
+	"stddev_over_time":             fn(ValueVector, ValueMatrix),
+	"stdvar_over_time":             fn(ValueVector, ValueMatrix),
+	"sum_over_time":                fn(ValueVector, ValueMatrix),
+	"tan":                          fn(ValueVector, ValueVector),
+	"tanh":                         fn(ValueVector, ValueVector),
+	"time":                         fn(ValueScalar),
+	"timestamp":                    fn(ValueVector, ValueVector),
+	"vector":                       fn(ValueVector, ValueScalar),
+	"year":                         optional(1, ValueVector, ValueVector),
+}
+
+// aggregations 为聚合运算符及其参数类型，不需要参数的为空字符串
+var aggregations = map[string]ValueType{
+	"avg":          "",
+	"bottomk":      ValueScalar,
+	"count":        "",
+	"count_values": ValueString,
+	"group":        "",
+	"limit_ratio":  ValueScalar,
+	"limitk":       ValueScalar,
+	"max":          "",
+	"min":          "",
+	"quantile":     ValueScalar,
+	"stddev":       "",
+	"stdvar":       "",
+	"sum":          "",
+	"topk":         ValueScalar,
+}
@@ -0,0 +1,256 @@
+// Package promql 在本地解析 PromQL 表达式并做类型检查，用于在发送请求前发现语法错误、
+// 未知函数和范围向量的误用。只检查表达式是否合法，不构建可执行的语法树。
+//
+// 函数表对应 Prometheus v3.0（保留 2.x 的 holt_winters，不含实验性的 info），
+// 服务端更新的函数可能不在表中，调用方可用 IsUnknownFunction 区分这类错误
+package promql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokDuration
+	tokString
+	tokLeftParen
+	tokRightParen
+	tokLeftBrace
+	tokRightBrace
+	tokLeftBracket
+	tokRightBracket
+	tokComma
+	tokColon
+	tokAt
+	// 运算符和标签匹配符
+	tokOperator
+)
+
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of input"
+	case tokString:
+		return "string " + t.val
+	case tokNumber:
+		return "number " + t.val
+	case tokDuration:
+		return "duration " + t.val
+	case tokIdent:
+		if isKeyword(t.val) {
+			return strings.ToLower(t.val)
+		}
+		return "identifier " + fmt.Sprintf("%q", t.val)
+	}
+	return fmt.Sprintf("%q", t.val)
+}
+
+// 按长度从长到短排列，保证优先匹配多字符运算符
+var operators = []string{"==", "!=", ">=", "<=", "=~", "!~", "+", "-", "*", "/", "%", "^", ">", "<", "="}
+
+// 时长单位，ms 必须在 m 之前匹配
+var durationUnits = []string{"ms", "s", "m", "h", "d", "w", "y"}
+
+// lex 将表达式切分为记号，遇到无法识别的字符时返回错误
+func lex(input string) ([]token, error) {
+	var tokens []token
+	// 方括号内只有时长，冒号是子查询的分隔符而不是指标名的一部分
+	inBracket := false
+	pos := 0
+	for pos < len(input) {
+		r, size := utf8.DecodeRuneInString(input[pos:])
+		switch {
+		case unicode.IsSpace(r):
+			pos += size
+			continue
+		case r == '#':
+			// 注释到行尾
+			for pos < len(input) && input[pos] != '\n' {
+				pos++
+			}
+			continue
+		}
+
+		start := pos
+		switch {
+		case r == '"' || r == '\'' || r == '`':
+			end, err := scanString(input, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokString, input[start:end], start})
+			pos = end
+		case isDigit(r) || (r == '.' && pos+1 < len(input) && isDigit(rune(input[pos+1]))):
+			kind, end, err := scanNumber(input, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind, input[start:end], start})
+			pos = end
+		case isIdentStart(r) && !(r == ':' && inBracket):
+			end := pos + 1
+			for end < len(input) && isIdentPart(rune(input[end])) {
+				end++
+			}
+			tokens = append(tokens, token{tokIdent, input[start:end], start})
+			pos = end
+		default:
+			kind, ok := punctuation[r]
+			if ok {
+				if kind == tokLeftBracket || kind == tokRightBracket {
+					inBracket = kind == tokLeftBracket
+				}
+				tokens = append(tokens, token{kind, string(r), start})
+				pos += size
+				continue
+			}
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(input[pos:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, &Error{Pos: pos, Msg: fmt.Sprintf("unexpected character %q", r)}
+			}
+			tokens = append(tokens, token{tokOperator, op, start})
+			pos += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(input)}), nil
+}
+
+var punctuation = map[rune]tokenKind{
+	'(': tokLeftParen,
+	')': tokRightParen,
+	'{': tokLeftBrace,
+	'}': tokRightBrace,
+	'[': tokLeftBracket,
+	']': tokRightBracket,
+	',': tokComma,
+	':': tokColon,
+	'@': tokAt,
+}
+
+// scanString 返回从 start 处引号开始的字符串的结束位置，反引号字符串不处理转义
+func scanString(input string, start int) (int, error) {
+	quote := input[start]
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case '\n':
+			if quote != '`' {
+				return 0, &Error{Pos: start, Msg: "unterminated quoted string"}
+			}
+		case quote:
+			return i + 1, nil
+		}
+	}
+	return 0, &Error{Pos: start, Msg: "unterminated quoted string"}
+}
+
+// scanNumber 扫描数字或时长，如 1、0.5、1e3、0x1f、5m、1h30m
+func scanNumber(input string, start int) (tokenKind, int, error) {
+	if end, ok := scanDuration(input, start); ok {
+		return tokDuration, end, nil
+	}
+
+	i := start
+	if strings.HasPrefix(input[i:], "0x") || strings.HasPrefix(input[i:], "0X") {
+		i += 2
+		for i < len(input) && strings.ContainsRune("0123456789abcdefABCDEF", rune(input[i])) {
+			i++
+		}
+	} else {
+		for i < len(input) && (isDigit(rune(input[i])) || input[i] == '.') {
+			i++
+		}
+		if i < len(input) && (input[i] == 'e' || input[i] == 'E') {
+			i++
+			if i < len(input) && (input[i] == '+' || input[i] == '-') {
+				i++
+			}
+			for i < len(input) && isDigit(rune(input[i])) {
+				i++
+			}
+		}
+	}
+	// 数字后紧跟字母说明既不是数字也不是合法的时长
+	if i < len(input) && isAlnum(rune(input[i])) {
+		return 0, 0, &Error{Pos: start, Msg: fmt.Sprintf("bad number or duration syntax %q", input[start:alnumEnd(input, i)])}
+	}
+	if strings.Count(input[start:i], ".") > 1 {
+		return 0, 0, &Error{Pos: start, Msg: fmt.Sprintf("bad number syntax %q", input[start:i])}
+	}
+	return tokNumber, i, nil
+}
+
+// scanDuration 尝试扫描由若干 <整数><单位> 组成的时长
+func scanDuration(input string, start int) (int, bool) {
+	i := start
+	matched := false
+	for i < len(input) && isDigit(rune(input[i])) {
+		j := i
+		for j < len(input) && isDigit(rune(input[j])) {
+			j++
+		}
+		unit := ""
+		for _, u := range durationUnits {
+			if strings.HasPrefix(input[j:], u) {
+				unit = u
+				break
+			}
+		}
+		if unit == "" {
+			return 0, false
+		}
+		i = j + len(unit)
+		matched = true
+	}
+	if !matched || (i < len(input) && (isAlnum(rune(input[i])) || input[i] == '.')) {
+		return 0, false
+	}
+	return i, true
+}
+
+func alnumEnd(input string, i int) int {
+	for i < len(input) && isAlnum(rune(input[i])) {
+		i++
+	}
+	return i
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// 指标名中允许冒号，用于录制规则
+func isIdentStart(r rune) bool {
+	return r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || isDigit(r)
+}
+
+// isAlnum 与 isIdentPart 相同但不含冒号，子查询中时长后紧跟的冒号是分隔符
+func isAlnum(r rune) bool {
+	return r != ':' && isIdentPart(r)
+}
@@ -0,0 +1,753 @@
+package promql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValueType 为表达式求值后的类型
+type ValueType string
+
+const (
+	ValueScalar ValueType = "scalar"
+	ValueVector ValueType = "instant vector"
+	ValueMatrix ValueType = "range vector"
+	ValueString ValueType = "string"
+)
+
+// Error 表示带位置的解析错误，Pos 为表达式中从 0 开始的字节偏移
+type Error struct {
+	Query string
+	Pos   int
+	Msg   string
+	// Function 为函数表中没有的函数名，其他错误为空
+	Function string
+}
+
+func (e *Error) Error() string {
+	line, col := e.position()
+	if strings.Contains(e.Query, "\n") {
+		return fmt.Sprintf("parse error at line %d, char %d: %s", line, col, e.Msg)
+	}
+	return fmt.Sprintf("parse error at char %d: %s", col, e.Msg)
+}
+
+// position 返回从 1 开始的行号和列号
+func (e *Error) position() (int, int) {
+	pos := e.Pos
+	if pos > len(e.Query) {
+		pos = len(e.Query)
+	}
+	before := e.Query[:pos]
+	line := strings.Count(before, "\n") + 1
+	col := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+	return line, col
+}
+
+// IsUnknownFunction 判断错误是否由函数表中没有的函数引起
+func IsUnknownFunction(err error) bool {
+	var perr *Error
+	return errors.As(err, &perr) && perr.Function != ""
+}
+
+// Context 返回出错的行和指向出错位置的 ^
+func (e *Error) Context() []string {
+	line, col := e.position()
+	text := strings.Split(e.Query, "\n")[line-1]
+	return []string{text, strings.Repeat(" ", col-1) + "^"}
+}
+
+// Check 解析表达式并返回其结果类型，表达式不合法时返回 *Error
+func Check(query string) (ValueType, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		err.(*Error).Query = query
+		return "", err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.parse()
+	if err != nil {
+		err.(*Error).Query = query
+		return "", err
+	}
+	return n.typ, nil
+}
+
+// 节点种类，决定后面能否跟范围、offset 和 @ 修饰符
+type nodeKind int
+
+const (
+	kindOther nodeKind = iota
+	kindNumber
+	kindVectorSelector
+	kindMatrixSelector
+	kindSubquery
+)
+
+type node struct {
+	typ    ValueType
+	kind   nodeKind
+	pos    int
+	offset bool
+	at     bool
+}
+
+// 二元运算符的优先级，数值越大结合越紧
+var precedence = map[string]int{
+	"or":     1,
+	"and":    2,
+	"unless": 2,
+	"==":     3,
+	"!=":     3,
+	">":      3,
+	"<":      3,
+	">=":     3,
+	"<=":     3,
+	"+":      4,
+	"-":      4,
+	"*":      5,
+	"/":      5,
+	"%":      5,
+	"atan2":  5,
+	"^":      6,
+}
+
+var keywords = []string{"and", "or", "unless", "atan2", "by", "without", "on", "ignoring", "group_left", "group_right", "offset", "bool"}
+
+// isKeyword 判断标识符是否为关键字，关键字不区分大小写
+func isKeyword(s string) bool {
+	for _, k := range keywords {
+		if strings.EqualFold(s, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func isComparison(op string) bool {
+	return precedence[op] == 3
+}
+
+func isSetOperator(op string) bool {
+	return op == "and" || op == "or" || op == "unless"
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) unexpected(t token, context string) error {
+	if context == "" {
+		return p.errorf(t.pos, "unexpected %s", t)
+	}
+	return p.errorf(t.pos, "unexpected %s in %s", t, context)
+}
+
+// expect 读取指定种类的记号，否则报告 context 中出现了意外的记号
+func (p *parser) expect(kind tokenKind, context string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.unexpected(t, context)
+	}
+	return t, nil
+}
+
+// peekKeyword 判断下一个记号是否为指定关键字
+func (p *parser) peekKeyword(k string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && strings.EqualFold(t.val, k)
+}
+
+func (p *parser) parse() (node, error) {
+	n, err := p.parseExpr(0)
+	if err != nil {
+		return n, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		if t.kind == tokRightParen {
+			return n, p.errorf(t.pos, "unexpected right parenthesis ')'")
+		}
+		return n, p.unexpected(t, "")
+	}
+	return n, nil
+}
+
+// binaryOperator 返回下一个记号对应的二元运算符
+func (p *parser) binaryOperator() (string, bool) {
+	t := p.peek()
+	op := t.val
+	if t.kind == tokIdent {
+		op = strings.ToLower(t.val)
+	} else if t.kind != tokOperator {
+		return "", false
+	}
+	_, ok := precedence[op]
+	return op, ok
+}
+
+// parseExpr 按优先级解析二元表达式，只处理优先级不低于 minPrec 的运算符
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return lhs, err
+	}
+
+	for {
+		op, ok := p.binaryOperator()
+		if !ok || precedence[op] < minPrec {
+			return lhs, nil
+		}
+		opToken := p.next()
+
+		returnBool, matching, err := p.parseBinaryModifiers(op)
+		if err != nil {
+			return lhs, err
+		}
+
+		// ^ 为右结合
+		nextPrec := precedence[op] + 1
+		if op == "^" {
+			nextPrec = precedence[op]
+		}
+		rhs, err := p.parseExpr(nextPrec)
+		if err != nil {
+			return lhs, err
+		}
+		if lhs, err = p.checkBinary(opToken, op, lhs, rhs, returnBool, matching); err != nil {
+			return lhs, err
+		}
+	}
+}
+
+// parseBinaryModifiers 解析运算符后的 bool、on/ignoring 和 group_left/group_right
+func (p *parser) parseBinaryModifiers(op string) (returnBool bool, matching bool, err error) {
+	if p.peekKeyword("bool") {
+		t := p.next()
+		if !isComparison(op) {
+			return false, false, p.errorf(t.pos, "bool modifier can only be used on comparison operators")
+		}
+		returnBool = true
+	}
+
+	if p.peekKeyword("on") || p.peekKeyword("ignoring") {
+		p.next()
+		if _, err := p.parseLabelList("on/ignoring clause"); err != nil {
+			return false, false, err
+		}
+		matching = true
+
+		if p.peekKeyword("group_left") || p.peekKeyword("group_right") {
+			t := p.next()
+			if isSetOperator(op) {
+				return false, false, p.errorf(t.pos, "no grouping allowed for %q operation", op)
+			}
+			// group_left 和 group_right 的标签列表可省略
+			if p.peek().kind == tokLeftParen {
+				if _, err := p.parseLabelList("grouping clause"); err != nil {
+					return false, false, err
+				}
+			}
+		}
+	}
+	return returnBool, matching, nil
+}
+
+// checkBinary 检查二元运算两侧的类型并返回结果节点
+func (p *parser) checkBinary(opToken token, op string, lhs, rhs node, returnBool, matching bool) (node, error) {
+	for _, operand := range []node{lhs, rhs} {
+		if operand.typ != ValueScalar && operand.typ != ValueVector {
+			return lhs, p.errorf(operand.pos, "binary expression must contain only scalar and instant vector types, got %s", operand.typ)
+		}
+	}
+
+	bothScalar := lhs.typ == ValueScalar && rhs.typ == ValueScalar
+	switch {
+	case isSetOperator(op) && (lhs.typ == ValueScalar || rhs.typ == ValueScalar):
+		return lhs, p.errorf(opToken.pos, "set operator %q not allowed in binary scalar expression", op)
+	case isComparison(op) && bothScalar && !returnBool:
+		return lhs, p.errorf(opToken.pos, "comparisons between scalars must use BOOL modifier")
+	case matching && (lhs.typ == ValueScalar || rhs.typ == ValueScalar):
+		return lhs, p.errorf(opToken.pos, "vector matching only allowed between instant vectors")
+	}
+
+	typ := ValueVector
+	if bothScalar {
+		typ = ValueScalar
+	}
+	return node{typ: typ, pos: lhs.pos}, nil
+}
+
+// parseUnary 解析一元正负号，其优先级低于 ^，因此 -2^2 等于 -(2^2)
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if t.kind == tokOperator && (t.val == "-" || t.val == "+") {
+		p.next()
+		n, err := p.parseExpr(precedence["^"])
+		if err != nil {
+			return n, err
+		}
+		if n.typ != ValueScalar && n.typ != ValueVector {
+			return n, p.errorf(t.pos, "unary expression only allowed on expressions of type scalar or instant vector, got %s", n.typ)
+		}
+		// 负数字面量仍可作为 @ 等修饰符的参数
+		n.pos = t.pos
+		if n.kind != kindNumber {
+			n.kind = kindOther
+		}
+		return n, nil
+	}
+
+	n, err := p.parsePrimary()
+	if err != nil {
+		return n, err
+	}
+	return p.parsePostfix(n)
+}
+
+// parsePostfix 解析表达式后的范围、子查询、offset 和 @ 修饰符
+func (p *parser) parsePostfix(n node) (node, error) {
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokLeftBracket:
+			var err error
+			if n, err = p.parseRange(n); err != nil {
+				return n, err
+			}
+		case p.peekKeyword("offset"):
+			p.next()
+			if err := p.checkModifiable(n, t, "offset"); err != nil {
+				return n, err
+			}
+			if n.offset {
+				return n, p.errorf(t.pos, "offset may not be set multiple times")
+			}
+			if next := p.peek(); next.kind == tokOperator && (next.val == "-" || next.val == "+") {
+				p.next()
+			}
+			if _, err := p.expect(tokDuration, "offset"); err != nil {
+				return n, err
+			}
+			n.offset = true
+		case t.kind == tokAt:
+			p.next()
+			if err := p.checkModifiable(n, t, "@"); err != nil {
+				return n, err
+			}
+			if n.at {
+				return n, p.errorf(t.pos, "@ <timestamp> may not be set multiple times")
+			}
+			if err := p.parseAt(); err != nil {
+				return n, err
+			}
+			n.at = true
+		default:
+			return n, nil
+		}
+	}
+}
+
+// checkModifiable 检查 offset 和 @ 是否跟在选择器或子查询之后
+func (p *parser) checkModifiable(n node, t token, modifier string) error {
+	switch n.kind {
+	case kindVectorSelector, kindMatrixSelector, kindSubquery:
+		return nil
+	}
+	return p.errorf(t.pos, "%s modifier must be preceded by an instant vector selector or range vector selector or a subquery", modifier)
+}
+
+// parseAt 解析 @ 后的时间戳、start() 或 end()
+func (p *parser) parseAt() error {
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		return nil
+	case t.kind == tokOperator && (t.val == "-" || t.val == "+"):
+		_, err := p.expect(tokNumber, "@")
+		return err
+	case t.kind == tokIdent && (t.val == "start" || t.val == "end"):
+		if _, err := p.expect(tokLeftParen, "@ "+t.val+"()"); err != nil {
+			return err
+		}
+		_, err := p.expect(tokRightParen, "@ "+t.val+"()")
+		return err
+	}
+	return p.unexpected(t, "@, expected timestamp, start() or end()")
+}
+
+// parseRange 解析 [range] 或 [range:step] 子查询
+func (p *parser) parseRange(n node) (node, error) {
+	open := p.next()
+	rangeToken := p.next()
+	if rangeToken.kind == tokRightBracket {
+		return n, p.errorf(rangeToken.pos, "missing range in square brackets")
+	}
+	if rangeToken.kind != tokDuration && rangeToken.kind != tokNumber {
+		return n, p.unexpected(rangeToken, "square brackets, expected duration")
+	}
+
+	t := p.next()
+	switch t.kind {
+	case tokRightBracket:
+		if n.kind != kindVectorSelector {
+			return n, p.errorf(open.pos, "ranges only allowed for vector selectors")
+		}
+		if n.offset || n.at {
+			return n, p.errorf(open.pos, "range must directly follow the vector selector, before offset and @")
+		}
+		return node{typ: ValueMatrix, kind: kindMatrixSelector, pos: n.pos}, nil
+	case tokColon:
+		if n.typ != ValueVector {
+			return n, p.errorf(open.pos, "subquery is only allowed on instant vector, got %s", n.typ)
+		}
+		step := p.next()
+		if step.kind == tokDuration || step.kind == tokNumber {
+			step = p.next()
+		}
+		if step.kind != tokRightBracket {
+			if step.kind == tokEOF {
+				return n, p.errorf(open.pos, "unclosed left bracket")
+			}
+			return n, p.unexpected(step, "subquery, expected step")
+		}
+		return node{typ: ValueMatrix, kind: kindSubquery, pos: n.pos}, nil
+	case tokEOF:
+		return n, p.errorf(open.pos, "unclosed left bracket")
+	}
+	return n, p.unexpected(t, "square brackets, expected \"]\" or \":\"")
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		if _, err := parseNumber(t.val); err != nil {
+			return node{}, p.errorf(t.pos, "bad number syntax %q", t.val)
+		}
+		return node{typ: ValueScalar, kind: kindNumber, pos: t.pos}, nil
+	case tokDuration:
+		return node{}, p.errorf(t.pos, "unexpected duration %s, durations are only allowed in brackets and after offset", t.val)
+	case tokString:
+		return node{typ: ValueString, pos: t.pos}, nil
+	case tokLeftParen:
+		n, err := p.parseExpr(0)
+		if err != nil {
+			return n, err
+		}
+		closing := p.next()
+		if closing.kind == tokEOF {
+			return n, p.errorf(t.pos, "unclosed left parenthesis")
+		}
+		if closing.kind != tokRightParen {
+			return n, p.unexpected(closing, "parenthesized expression")
+		}
+		return node{typ: n.typ, pos: t.pos}, nil
+	case tokLeftBrace:
+		p.pos--
+		return p.parseSelector(t, "")
+	case tokIdent:
+		return p.parseIdentifier(t)
+	case tokEOF:
+		return node{}, p.errorf(t.pos, "unexpected end of input")
+	case tokRightParen:
+		return node{}, p.errorf(t.pos, "unexpected right parenthesis ')'")
+	}
+	return node{}, p.unexpected(t, "")
+}
+
+// parseIdentifier 解析以标识符开头的数字、聚合、函数调用或选择器
+func (p *parser) parseIdentifier(t token) (node, error) {
+	lower := strings.ToLower(t.val)
+	if lower == "inf" || lower == "nan" {
+		return node{typ: ValueScalar, kind: kindNumber, pos: t.pos}, nil
+	}
+	if _, ok := aggregations[lower]; ok {
+		return p.parseAggregation(t, lower)
+	}
+	if isKeyword(t.val) {
+		return node{}, p.unexpected(t, "")
+	}
+	if p.peek().kind == tokLeftParen {
+		return p.parseCall(t)
+	}
+	return p.parseSelector(t, t.val)
+}
+
+// parseSelector 解析 metric{matchers}，name 为空时必须有花括号
+func (p *parser) parseSelector(start token, name string) (node, error) {
+	hasNonEmpty := name != ""
+	if p.peek().kind == tokLeftBrace {
+		nonEmpty, err := p.parseMatchers(name != "")
+		if err != nil {
+			return node{}, err
+		}
+		hasNonEmpty = hasNonEmpty || nonEmpty
+	}
+	if !hasNonEmpty {
+		return node{}, p.errorf(start.pos, "vector selector must contain at least one non-empty matcher")
+	}
+	return node{typ: ValueVector, kind: kindVectorSelector, pos: start.pos}, nil
+}
+
+// parseMatchers 解析花括号中的标签匹配器，返回是否有不匹配空字符串的匹配器
+func (p *parser) parseMatchers(hasName bool) (bool, error) {
+	open := p.next()
+	nonEmpty := false
+	for {
+		t := p.next()
+		switch t.kind {
+		case tokRightBrace:
+			return nonEmpty, nil
+		case tokEOF:
+			return false, p.errorf(open.pos, "unclosed left brace")
+		case tokString:
+			// {"metric.name"} 形式的指标名
+			if hasName {
+				return false, p.errorf(t.pos, "metric name must not be set twice")
+			}
+			hasName = true
+			nonEmpty = true
+		case tokIdent:
+			op, err := p.expect(tokOperator, "label matching")
+			if err != nil {
+				return false, err
+			}
+			switch op.val {
+			case "=", "!=", "=~", "!~":
+			default:
+				return false, p.unexpected(op, "label matching, expected one of \"=\", \"!=\", \"=~\" or \"!~\"")
+			}
+			value, err := p.expect(tokString, "label matching, expected string")
+			if err != nil {
+				return false, err
+			}
+			s, err := unquote(value.val)
+			if err != nil {
+				return false, p.errorf(value.pos, "%v", err)
+			}
+			if t.val == "__name__" {
+				if hasName && op.val == "=" {
+					return false, p.errorf(t.pos, "metric name must not be set twice")
+				}
+				hasName = true
+			}
+			matches := s == ""
+			if op.val == "=~" || op.val == "!~" {
+				re, err := regexp.Compile("^(?:" + s + ")$")
+				if err != nil {
+					return false, p.errorf(value.pos, "invalid regular expression in label matcher: %v", err)
+				}
+				matches = re.MatchString("")
+			}
+			if op.val == "!=" || op.val == "!~" {
+				matches = !matches
+			}
+			if !matches {
+				nonEmpty = true
+			}
+		default:
+			return false, p.unexpected(t, "label matching, expected label name")
+		}
+
+		switch t := p.next(); t.kind {
+		case tokComma:
+		case tokRightBrace:
+			return nonEmpty, nil
+		case tokEOF:
+			return false, p.errorf(open.pos, "unclosed left brace")
+		default:
+			return false, p.unexpected(t, "label matching, expected \",\" or \"}\"")
+		}
+	}
+}
+
+// parseCall 解析函数调用并检查参数个数和类型
+func (p *parser) parseCall(name token) (node, error) {
+	f, ok := functions[name.val]
+	if !ok {
+		err := p.errorf(name.pos, "unknown function with name %q", name.val)
+		err.(*Error).Function = name.val
+		return node{}, err
+	}
+	args, err := p.parseArgs(name.val)
+	if err != nil {
+		return node{}, err
+	}
+
+	maxArgs := len(f.Args)
+	switch {
+	case len(args) < f.MinArgs || (!f.Variadic && len(args) > maxArgs):
+		want := fmt.Sprintf("%d", f.MinArgs)
+		if f.Variadic {
+			want = fmt.Sprintf("at least %d", f.MinArgs)
+		} else if f.MinArgs != maxArgs {
+			want = fmt.Sprintf("%d to %d", f.MinArgs, maxArgs)
+		}
+		return node{}, p.errorf(name.pos, "expected %s argument(s) in call to %q, got %d", want, name.val, len(args))
+	}
+
+	for i, arg := range args {
+		want := f.Args[len(f.Args)-1]
+		if i < len(f.Args) {
+			want = f.Args[i]
+		}
+		if arg.typ != want {
+			return node{}, p.errorf(arg.pos, "expected type %s in call to function %q, got %s", want, name.val, arg.typ)
+		}
+	}
+	return node{typ: f.ReturnType, pos: name.pos}, nil
+}
+
+// parseArgs 解析括号中以逗号分隔的参数，允许末尾多一个逗号
+func (p *parser) parseArgs(context string) ([]node, error) {
+	open, err := p.expect(tokLeftParen, context)
+	if err != nil {
+		return nil, err
+	}
+	var args []node
+	if p.peek().kind == tokRightParen {
+		p.next()
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		t := p.next()
+		switch t.kind {
+		case tokRightParen:
+			return args, nil
+		case tokComma:
+			if p.peek().kind == tokRightParen {
+				p.next()
+				return args, nil
+			}
+		case tokEOF:
+			return nil, p.errorf(open.pos, "unclosed left parenthesis")
+		default:
+			return nil, p.unexpected(t, "argument list of "+context)
+		}
+	}
+}
+
+// parseAggregation 解析 sum by (a) (x) 或 sum(x) by (a) 形式的聚合
+func (p *parser) parseAggregation(name token, op string) (node, error) {
+	grouped := false
+	if p.peekKeyword("by") || p.peekKeyword("without") {
+		p.next()
+		if _, err := p.parseLabelList("grouping clause"); err != nil {
+			return node{}, err
+		}
+		grouped = true
+	}
+	if p.peek().kind != tokLeftParen {
+		return node{}, p.unexpected(p.peek(), "aggregation, expected \"(\"")
+	}
+	args, err := p.parseArgs(op + " aggregation")
+	if err != nil {
+		return node{}, err
+	}
+	if !grouped && (p.peekKeyword("by") || p.peekKeyword("without")) {
+		p.next()
+		if _, err := p.parseLabelList("grouping clause"); err != nil {
+			return node{}, err
+		}
+	}
+
+	param := aggregations[op]
+	want := 1
+	if param != "" {
+		want = 2
+	}
+	if len(args) != want {
+		return node{}, p.errorf(name.pos, "wrong number of arguments for aggregate expression provided, expected %d, got %d", want, len(args))
+	}
+	if param != "" && args[0].typ != param {
+		return node{}, p.errorf(args[0].pos, "expected type %s in %s aggregation parameter, got %s", param, op, args[0].typ)
+	}
+	if expr := args[len(args)-1]; expr.typ != ValueVector {
+		return node{}, p.errorf(expr.pos, "expected type %s in aggregation expression, got %s", ValueVector, expr.typ)
+	}
+	return node{typ: ValueVector, pos: name.pos}, nil
+}
+
+// parseLabelList 解析 (a, b) 形式的标签列表
+func (p *parser) parseLabelList(context string) ([]string, error) {
+	open, err := p.expect(tokLeftParen, context)
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	for {
+		t := p.next()
+		switch t.kind {
+		case tokRightParen:
+			return labels, nil
+		case tokIdent, tokString:
+			labels = append(labels, t.val)
+		case tokEOF:
+			return nil, p.errorf(open.pos, "unclosed left parenthesis")
+		default:
+			return nil, p.unexpected(t, context+", expected label")
+		}
+
+		switch t := p.next(); t.kind {
+		case tokComma:
+		case tokRightParen:
+			return labels, nil
+		case tokEOF:
+			return nil, p.errorf(open.pos, "unclosed left parenthesis")
+		default:
+			return nil, p.unexpected(t, context+", expected \",\" or \")\"")
+		}
+	}
+}
+
+func parseNumber(s string) (float64, error) {
+	if strings.HasPrefix(strings.ToLower(s), "0x") {
+		v, err := strconv.ParseInt(s[2:], 16, 64)
+		return float64(v), err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// unquote 去掉字符串的引号并处理转义，单引号字符串按双引号规则处理
+func unquote(s string) (string, error) {
+	switch s[0] {
+	case '`':
+		return s[1 : len(s)-1], nil
+	case '\'':
+		body := strings.ReplaceAll(s[1:len(s)-1], `\'`, `'`)
+		body = strings.ReplaceAll(body, `"`, `\"`)
+		s = `"` + body + `"`
+	}
+	v, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid escape sequence in string %s", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,105 @@
+package promql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		query string
+		want  ValueType
+	}{
+		{`up`, ValueVector},
+		{`up{job="node", instance=~"10\\.0\\..*"}`, ValueVector},
+		{`{__name__="up"}`, ValueVector},
+		{`node_cpu_seconds_total[5m]`, ValueMatrix},
+		{`rate(http_requests_total{code!~"5.."}[5m] offset -1h)`, ValueVector},
+		{`sum by (job) (rate(x[5m])) / on(job) group_left(team) sum(y) without (instance)`, ValueVector},
+		{`histogram_quantile(0.99, sum(rate(h_bucket[5m])) by (le))`, ValueVector},
+		{`topk(3, up) and up > bool 0`, ValueVector},
+		{`max_over_time(rate(x[1m])[1h:5m])`, ValueVector},
+		{`avg_over_time(up[1h:] @ end())`, ValueVector},
+		{`up @ 1700000000 offset 5m`, ValueVector},
+		{`-2 ^ 2 + time()`, ValueScalar},
+		{`1 > bool 2`, ValueScalar},
+		{`label_join(up, "dst", ",", "a", "b", "c")`, ValueVector},
+		{`round(up)`, ValueVector},
+		{`count_values("version", build_info)`, ValueVector},
+		{"up{job=`node`} # comment", ValueVector},
+		{`"text"`, ValueString},
+		{`job:up:rate5m`, ValueVector},
+		{`0x1f * Inf`, ValueScalar},
+		{`SUM(up) BY (job)`, ValueVector},
+	}
+
+	for _, tt := range tests {
+		got, err := Check(tt.query)
+		if err != nil {
+			t.Errorf("Check(%q) returned error: %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Check(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`sum(rate(x[5m])`, "char 4: unclosed left parenthesis"},
+		{`rate(x)`, `char 6: expected type range vector in call to function "rate", got instant vector`},
+		{`rat(x[5m])`, `char 1: unknown function with name "rat"`},
+		{`sum(x[5m])`, "expected type instant vector in aggregation expression, got range vector"},
+		{`rate(x[5m])[5m]`, "ranges only allowed for vector selectors"},
+		{`x[5m] + 1`, "binary expression must contain only scalar and instant vector types, got range vector"},
+		{`1 > 2`, "comparisons between scalars must use BOOL modifier"},
+		{`1 and up`, `set operator "and" not allowed in binary scalar expression`},
+		{`up{job=~"a("}`, "invalid regular expression"},
+		{`{job=""}`, "vector selector must contain at least one non-empty matcher"},
+		{`topk(up)`, "wrong number of arguments for aggregate expression provided, expected 2, got 1"},
+		{`up{job="node}`, "char 8: unterminated quoted string"},
+		{`sum(up) offset 5m`, "offset modifier must be preceded by an instant vector selector"},
+		{`up[5x]`, `bad number or duration syntax "5x"`},
+		{"up +\n  foo)", "line 2, char 6: unexpected right parenthesis"},
+		{`up up`, `unexpected identifier "up"`},
+		{`clamp_max(up)`, `expected 2 argument(s) in call to "clamp_max", got 1`},
+		{``, "unexpected end of input"},
+	}
+
+	for _, tt := range tests {
+		_, err := Check(tt.query)
+		if err == nil {
+			t.Errorf("Check(%q) returned no error, want %q", tt.query, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Check(%q) error = %q, want it to contain %q", tt.query, err.Error(), tt.want)
+		}
+	}
+}
+
+func TestErrorContext(t *testing.T) {
+	_, err := Check("sum(rate(x[5m])) by (job) +\nrate(y)")
+	perr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Check returned %T, want *Error", err)
+	}
+	want := []string{"rate(y)", "     ^"}
+	got := perr.Context()
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Context() = %q, want %q", got, want)
+	}
+}
+
+func TestIsUnknownFunction(t *testing.T) {
+	if _, err := Check(`sum(info(up))`); !IsUnknownFunction(err) {
+		t.Errorf("Check(info) error = %v, want an unknown function error", err)
+	}
+	if _, err := Check(`rate(up)`); IsUnknownFunction(err) {
+		t.Errorf("Check(rate(up)) error = %v reported as unknown function", err)
+	}
+}